@@ -3,9 +3,12 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"regexp"
 
+	"github.com/devusSs/steamquery-v2/sheetref"
 	"github.com/devusSs/steamquery-v2/utils"
 )
 
@@ -22,6 +25,130 @@ type OrgCells struct {
 	DifferenceCell  string `json:"difference_cell"`
 }
 
+type SteamAccount struct {
+	Label        string `json:"label"`
+	SteamID64    uint64 `json:"steam_user_id_64"`
+	AmountColumn string `json:"amount_column"`
+}
+
+// Optional header texts used to find the item, price, price total and amount columns by header.
+//
+// A header overrides the corresponding column letter, headers are looked up in the header row.
+type ColumnHeaders struct {
+	HeaderRow  int    `json:"header_row"`
+	Item       string `json:"item"`
+	Price      string `json:"price"`
+	PriceTotal string `json:"price_total"`
+	Amount     string `json:"amount"`
+}
+
+// Returns true if any column is referenced by header.
+func (c ColumnHeaders) IsSet() bool {
+	return c.Item != "" || c.Price != "" || c.PriceTotal != "" || c.Amount != ""
+}
+
+// Optional columns for item metadata from the inventory tags, empty columns are skipped.
+type MetadataColumns struct {
+	Rarity     string `json:"rarity"`
+	Type       string `json:"type"`
+	Collection string `json:"collection"`
+	Exterior   string `json:"exterior"`
+}
+
+// Category of items with its own total and difference cell.
+//
+// Items are selected either by row range, by metadata tag (rarity, type, collection or exterior)
+// or by a regular expression matched against the item name.
+type Category struct {
+	Name           string `json:"name"`
+	StartRow       int    `json:"start_row"`
+	EndRow         int    `json:"end_row"`
+	Tag            string `json:"tag"`
+	NamePattern    string `json:"name_pattern"`
+	TotalValueCell string `json:"total_value_cell"`
+	DifferenceCell string `json:"difference_cell"`
+}
+
+// Optional cells and column for items which can not be traded or sold yet.
+type TradeHolds struct {
+	LiquidValueCell   string `json:"liquid_value_cell"`
+	LockedValueCell   string `json:"locked_value_cell"`
+	HoldColumn        string `json:"hold_column"`
+	ExpiryWarningDays int    `json:"expiry_warning_days"`
+}
+
+// Returns true if any trade hold cell or column is set.
+func (t TradeHolds) IsSet() bool {
+	return t.LiquidValueCell != "" || t.LockedValueCell != "" || t.HoldColumn != ""
+}
+
+// Optional formatting of price and total cells by their change since the previous run.
+//
+// Style is either background or text, empty disables the formatting.
+type PriceFormatting struct {
+	Style       string `json:"style"`
+	DeltaColumn string `json:"delta_column"`
+}
+
+// Optional tab in the spreadsheet which gets one row appended per run, empty tab disables it.
+type PriceHistory struct {
+	Tab          string `json:"tab"`
+	IncludeItems bool   `json:"include_items"`
+}
+
+// Returns the first cell of the price history tab.
+func (p PriceHistory) GetStartCell() string {
+	return sheetref.JoinReference(p.Tab, "A1")
+}
+
+// Decides which Steam services need to be up before a run.
+type SteamHealth struct {
+	RequiredServices   []string `json:"required_services"`
+	TreatDelayedAsDown bool     `json:"treat_delayed_as_down"`
+	SkipForPriceRuns   bool     `json:"skip_for_price_runs"`
+}
+
+// Spreadsheet backend, Google Sheets (default) or a local json, csv or xlsx file.
+type SheetBackend struct {
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	SheetName string `json:"sheet_name"`
+}
+
+// Returns true if the sheet is a local file instead of a Google sheet.
+func (s SheetBackend) IsLocal() bool {
+	return s.Type != "" && s.Type != "google"
+}
+
+// Retry and request budget settings for the Google Sheets API.
+type GoogleAPI struct {
+	MaxRetries        int `json:"max_retries"`
+	RequestsPerMinute int `json:"requests_per_minute"`
+}
+
+// Google authentication, file (the -g credentials file, default), adc, env or oauth.
+type GoogleAuth struct {
+	Type             string `json:"type"`
+	CredentialsEnv   string `json:"credentials_env"`
+	ClientSecretFile string `json:"client_secret_file"`
+	TokenFile        string `json:"token_file"`
+}
+
+// Returns true if the -g service account credentials file is used.
+func (g GoogleAuth) UsesCredentialsFile() bool {
+	return g.Type == "" || g.Type == "file"
+}
+
+type Endpoints struct {
+	SteamStatusURL    string `json:"steam_status_url"`
+	SteamPriceURL     string `json:"steam_price_url"`
+	SteamInventoryURL string `json:"steam_inventory_url"`
+	SteamTimeout      int    `json:"steam_timeout"`
+	SteamPriceTimeout int    `json:"steam_price_timeout"`
+	GithubReleaseURL  string `json:"github_release_url"`
+	GithubTimeout     int    `json:"github_timeout"`
+}
+
 type Postgres struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
@@ -31,28 +158,45 @@ type Postgres struct {
 }
 
 type WatchDog struct {
-	RetryInterval      int      `json:"retry_interval"`
-	SteamRetryInterval int      `json:"steam_retry_interval"`
-	MaxPriceDrop       float64  `json:"max_price_drop"`
-	SMTPHost           string   `json:"smtp_host"`
-	SMTPPort           int      `json:"smtp_port"`
-	SMTPUser           string   `json:"smtp_user"`
-	SMTPPassword       string   `json:"smtp_password"`
-	SMTPFrom           string   `json:"smtp_from"`
-	SMTPTo             string   `json:"smtp_to"`
-	Postgres           Postgres `json:"postgres"`
+	RetryInterval         int      `json:"retry_interval"`
+	SteamRetryInterval    int      `json:"steam_retry_interval"`
+	SteamRetryMaxAttempts int      `json:"steam_retry_max_attempts"`
+	SteamRetryDeadline    int      `json:"steam_retry_deadline"`
+	SteamRetryBackoff     float64  `json:"steam_retry_backoff"`
+	OverlapPolicy         string   `json:"overlap_policy"`
+	MaxPriceDrop          float64  `json:"max_price_drop"`
+	SMTPHost              string   `json:"smtp_host"`
+	SMTPPort              int      `json:"smtp_port"`
+	SMTPUser              string   `json:"smtp_user"`
+	SMTPPassword          string   `json:"smtp_password"`
+	SMTPFrom              string   `json:"smtp_from"`
+	SMTPTo                string   `json:"smtp_to"`
+	Postgres              Postgres `json:"postgres"`
 }
 
 type Config struct {
-	ItemList         ItemList `json:"item_list"`
-	PriceColumn      string   `json:"price_column"`
-	PriceTotalColumn string   `json:"price_total_column"`
-	AmountColumn     string   `json:"amount_column"`
-	OrgCells         OrgCells `json:"org_cells"`
-	SpreadSheetID    string   `json:"spread_sheet_id"`
-	SteamAPIKey      string   `json:"steam_api_key"`
-	SteamUserID64    uint64   `json:"steam_user_id_64"`
-	WatchDog         WatchDog `json:"watch_dog"`
+	ItemList         ItemList        `json:"item_list"`
+	PriceColumn      string          `json:"price_column"`
+	PriceTotalColumn string          `json:"price_total_column"`
+	AmountColumn     string          `json:"amount_column"`
+	ColumnHeaders    ColumnHeaders   `json:"column_headers"`
+	MetadataColumns  MetadataColumns `json:"metadata_columns"`
+	Categories       []Category      `json:"categories"`
+	TradeHolds       TradeHolds      `json:"trade_holds"`
+	PriceFormatting  PriceFormatting `json:"price_formatting"`
+	PriceHistory     PriceHistory    `json:"price_history"`
+	OrgCells         OrgCells        `json:"org_cells"`
+	SpreadSheetID    string          `json:"spread_sheet_id"`
+	SheetBackend     SheetBackend    `json:"sheet_backend"`
+	GoogleAPI        GoogleAPI       `json:"google_api"`
+	GoogleAuth       GoogleAuth      `json:"google_auth"`
+	SteamAPIKey      string          `json:"steam_api_key"`
+	SteamUserID64    uint64          `json:"steam_user_id_64"`
+	SteamAccounts    []SteamAccount  `json:"steam_accounts"`
+	RunCooldown      int             `json:"run_cooldown"`
+	SteamHealth      SteamHealth     `json:"steam_health"`
+	Endpoints        Endpoints       `json:"endpoints"`
+	WatchDog         WatchDog        `json:"watch_dog"`
 }
 
 func LoadConfig(configPath string) (*Config, error) {
@@ -76,29 +220,117 @@ func LoadConfig(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Writes the config as indented JSON, existing files are not overwritten.
+//
+// The file may hold secrets (api key, smtp password), so only the owner may read it.
+func WriteConfig(cfg *Config, configPath string) error {
+	cfgBody, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(cfgBody, '\n')); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Returns all configured Steam accounts, the single steam user id 64 is labeled "main".
+func (c *Config) GetSteamAccounts() []SteamAccount {
+	if c.SteamUserID64 == 0 {
+		return c.SteamAccounts
+	}
+
+	accounts := []SteamAccount{{Label: "main", SteamID64: c.SteamUserID64}}
+
+	return append(accounts, c.SteamAccounts...)
+}
+
+// Returns true if the item list column references a named range instead of a column letter.
+func (i ItemList) IsNamedRange() bool {
+	return sheetref.IsNamedRange(i.ColumnLetter)
+}
+
+// Returns all configured cell references and the item list for checking them against the sheet.
+func (c *Config) GetSheetReferences() []string {
+	refs := []string{
+		c.OrgCells.LastUpdatedCell,
+		c.OrgCells.ErrorCell,
+		c.OrgCells.TotalValueCell,
+		c.OrgCells.DifferenceCell,
+	}
+
+	for _, category := range c.Categories {
+		refs = append(refs, category.TotalValueCell, category.DifferenceCell)
+	}
+
+	refs = append(refs, c.TradeHolds.LiquidValueCell, c.TradeHolds.LockedValueCell)
+
+	if c.PriceHistory.Tab != "" {
+		refs = append(refs, c.PriceHistory.GetStartCell())
+	}
+
+	switch {
+	case c.ItemList.IsNamedRange():
+		refs = append(refs, c.ItemList.ColumnLetter)
+	case c.ItemList.ColumnLetter != "":
+		refs = append(refs, fmt.Sprintf("%s%d", c.ItemList.ColumnLetter, c.ItemList.StartNumber))
+	}
+
+	var configured []string
+	for _, ref := range refs {
+		if ref != "" {
+			configured = append(configured, ref)
+		}
+	}
+
+	return configured
+}
+
+// Sets the item list resolved from a named range and checks the rows depending on it.
+func (c *Config) SetItemList(column string, startNumber, endNumber int) error {
+	c.ItemList = ItemList{ColumnLetter: column, StartNumber: startNumber, EndNumber: endNumber}
+
+	return c.checkItemRows()
+}
+
 func (c *Config) CheckConfig(watchDog bool) error {
-	if c.ItemList.ColumnLetter == "" {
-		return errors.New("missing item list column letter in config")
+	if c.ItemList.ColumnLetter == "" && c.ColumnHeaders.Item == "" {
+		return errors.New("missing item list column letter or item header in config")
 	}
 
-	if c.ItemList.StartNumber == 0 {
-		return errors.New("missing item list start number in config")
+	// Named item lists get their rows from the sheet on startup.
+	if !c.ItemList.IsNamedRange() {
+		if c.ItemList.StartNumber == 0 {
+			return errors.New("missing item list start number in config")
+		}
+
+		if c.ItemList.EndNumber == 0 {
+			return errors.New("missing item list end number in config")
+		}
 	}
 
-	if c.ItemList.EndNumber == 0 {
-		return errors.New("missing item list end number in config")
+	if c.PriceColumn == "" && c.ColumnHeaders.Price == "" {
+		return errors.New("missing price column or price header in config")
 	}
 
-	if c.PriceColumn == "" {
-		return errors.New("missing price column in config")
+	if c.PriceTotalColumn == "" && c.ColumnHeaders.PriceTotal == "" {
+		return errors.New("missing price total column or price total header in config")
 	}
 
-	if c.PriceTotalColumn == "" {
-		return errors.New("missing price total column in config")
+	if c.AmountColumn == "" && c.ColumnHeaders.Amount == "" {
+		return errors.New("missing amount column or amount header in config")
 	}
 
-	if c.AmountColumn == "" {
-		return errors.New("missing amount column in config")
+	if c.ColumnHeaders.IsSet() && c.ColumnHeaders.HeaderRow < 1 {
+		return errors.New("missing header row for column headers in config")
 	}
 
 	if c.OrgCells.DifferenceCell == "" {
@@ -117,16 +349,175 @@ func (c *Config) CheckConfig(watchDog bool) error {
 		return errors.New("missing last updated cell in config")
 	}
 
-	if c.SpreadSheetID == "" {
-		return errors.New("missing spreadsheet id in config")
+	if err := c.checkSheetReferences(); err != nil {
+		return err
+	}
+
+	switch c.SheetBackend.Type {
+	case "", "google":
+		if c.SpreadSheetID == "" {
+			return errors.New("missing spreadsheet id in config")
+		}
+	case "json", "csv", "xlsx":
+		if c.SheetBackend.Path == "" {
+			return fmt.Errorf("missing path for %s sheet backend in config", c.SheetBackend.Type)
+		}
+	default:
+		return fmt.Errorf(
+			"unsupported sheet backend: %s, want google, json, csv or xlsx",
+			c.SheetBackend.Type,
+		)
+	}
+
+	if c.PriceHistory.Tab != "" {
+		if c.SheetBackend.IsLocal() {
+			return errors.New("price history tab is only supported by Google Sheets")
+		}
+
+		if err := sheetref.ValidateReference(c.PriceHistory.GetStartCell()); err != nil {
+			return fmt.Errorf("price history tab: %s", err.Error())
+		}
+	}
+
+	switch c.GoogleAuth.Type {
+	case "", "file", "adc", "env":
+	case "oauth":
+		if c.GoogleAuth.ClientSecretFile == "" {
+			return errors.New("missing client secret file for oauth google auth in config")
+		}
+	default:
+		return fmt.Errorf(
+			"unsupported google auth type: %s, want file, adc, env or oauth",
+			c.GoogleAuth.Type,
+		)
+	}
+
+	if c.GoogleAPI.MaxRetries < -1 {
+		return errors.New("google api max retries may not be less than -1")
+	}
+
+	if c.GoogleAPI.RequestsPerMinute < 0 {
+		return errors.New("google api requests per minute may not be negative")
 	}
 
 	if c.SteamAPIKey == "" {
 		return errors.New("missing steam api key in config")
 	}
 
-	if c.SteamUserID64 == 0 {
-		return errors.New("missing steam user id 64 in config")
+	if c.SteamUserID64 == 0 && len(c.SteamAccounts) == 0 {
+		return errors.New("missing steam user id 64 or steam accounts in config")
+	}
+
+	accountLabels := make(map[string]bool)
+
+	for _, account := range c.GetSteamAccounts() {
+		if account.Label == "" {
+			return errors.New("missing steam account label in config")
+		}
+
+		if account.SteamID64 == 0 {
+			return fmt.Errorf("missing steam user id 64 for steam account %s in config", account.Label)
+		}
+
+		// Reserved for storage unit contents in inventory snapshots.
+		if account.Label == "storage_units" {
+			return errors.New("steam account label storage_units is reserved")
+		}
+
+		if accountLabels[account.Label] {
+			return fmt.Errorf("duplicate steam account label in config: %s", account.Label)
+		}
+
+		accountLabels[account.Label] = true
+	}
+
+	categoryNames := make(map[string]bool)
+
+	for _, category := range c.Categories {
+		if category.Name == "" {
+			return errors.New("missing category name in config")
+		}
+
+		if categoryNames[category.Name] {
+			return fmt.Errorf("duplicate category name in config: %s", category.Name)
+		}
+
+		categoryNames[category.Name] = true
+
+		selectors := 0
+
+		if category.StartRow != 0 || category.EndRow != 0 {
+			selectors++
+		}
+
+		if category.Tag != "" {
+			selectors++
+		}
+
+		if category.NamePattern != "" {
+			selectors++
+
+			if _, err := regexp.Compile(category.NamePattern); err != nil {
+				return fmt.Errorf("invalid name pattern for category %s: %s", category.Name, err.Error())
+			}
+		}
+
+		if selectors != 1 {
+			return fmt.Errorf(
+				"category %s needs exactly one of row range, tag or name pattern",
+				category.Name,
+			)
+		}
+
+		if category.TotalValueCell == "" {
+			return fmt.Errorf("missing total value cell for category %s in config", category.Name)
+		}
+	}
+
+	if !c.ItemList.IsNamedRange() {
+		if err := c.checkItemRows(); err != nil {
+			return err
+		}
+	}
+
+	if c.TradeHolds.ExpiryWarningDays < 0 {
+		return errors.New("trade hold expiry warning days may not be negative")
+	}
+
+	switch c.PriceFormatting.Style {
+	case "", "background", "text":
+	default:
+		return fmt.Errorf(
+			"unsupported price formatting style: %s, want background or text",
+			c.PriceFormatting.Style,
+		)
+	}
+
+	for _, service := range c.SteamHealth.RequiredServices {
+		switch service {
+		case "SessionsLogon", "SteamCommunity", "IEconItems", "Leaderboards":
+		default:
+			return fmt.Errorf(
+				"unsupported required steam service: %s, want SessionsLogon, SteamCommunity, IEconItems or Leaderboards",
+				service,
+			)
+		}
+	}
+
+	if c.RunCooldown < 0 {
+		return errors.New("run cooldown may not be negative")
+	}
+
+	if c.Endpoints.SteamTimeout < 0 {
+		return errors.New("steam timeout may not be negative")
+	}
+
+	if c.Endpoints.SteamPriceTimeout < 0 {
+		return errors.New("steam price timeout may not be negative")
+	}
+
+	if c.Endpoints.GithubTimeout < 0 {
+		return errors.New("github timeout may not be negative")
 	}
 
 	if watchDog {
@@ -142,6 +533,27 @@ func (c *Config) CheckConfig(watchDog bool) error {
 			return errors.New("steam retry interval needs to be at least 5 minutes")
 		}
 
+		if c.WatchDog.SteamRetryMaxAttempts < 0 {
+			return errors.New("steam retry max attempts may not be negative")
+		}
+
+		if c.WatchDog.SteamRetryDeadline < 0 {
+			return errors.New("steam retry deadline may not be negative")
+		}
+
+		if c.WatchDog.SteamRetryBackoff != 0 && c.WatchDog.SteamRetryBackoff < 1 {
+			return errors.New("steam retry backoff needs to be at least 1")
+		}
+
+		switch c.WatchDog.OverlapPolicy {
+		case "", "drop", "queue":
+		default:
+			return fmt.Errorf(
+				"unsupported overlap policy: %s, want drop or queue",
+				c.WatchDog.OverlapPolicy,
+			)
+		}
+
 		if c.WatchDog.SMTPHost == "" {
 			return errors.New("missing smpt host in config")
 		}
@@ -197,3 +609,92 @@ func (c *Config) CheckConfig(watchDog bool) error {
 
 	return nil
 }
+
+// Helper function which validates the cell references and columns, optionally with tab.
+func (c *Config) checkSheetReferences() error {
+	cells := map[string]string{
+		"last updated cell": c.OrgCells.LastUpdatedCell,
+		"error cell":        c.OrgCells.ErrorCell,
+		"total value cell":  c.OrgCells.TotalValueCell,
+		"difference cell":   c.OrgCells.DifferenceCell,
+		"liquid value cell": c.TradeHolds.LiquidValueCell,
+		"locked value cell": c.TradeHolds.LockedValueCell,
+	}
+
+	for _, category := range c.Categories {
+		cells[fmt.Sprintf("total value cell of category %s", category.Name)] = category.TotalValueCell
+		cells[fmt.Sprintf("difference cell of category %s", category.Name)] = category.DifferenceCell
+	}
+
+	for name, cell := range cells {
+		if cell == "" {
+			continue
+		}
+
+		if err := sheetref.ValidateReference(cell); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	columns := map[string]string{
+		"price column":       c.PriceColumn,
+		"price total column": c.PriceTotalColumn,
+		"amount column":      c.AmountColumn,
+		"rarity column":      c.MetadataColumns.Rarity,
+		"type column":        c.MetadataColumns.Type,
+		"collection column":  c.MetadataColumns.Collection,
+		"exterior column":    c.MetadataColumns.Exterior,
+		"hold column":        c.TradeHolds.HoldColumn,
+		"delta column":       c.PriceFormatting.DeltaColumn,
+	}
+
+	if !c.ItemList.IsNamedRange() {
+		columns["item list column"] = c.ItemList.ColumnLetter
+	}
+
+	for _, account := range c.SteamAccounts {
+		columns[fmt.Sprintf("amount column of steam account %s", account.Label)] = account.AmountColumn
+	}
+
+	for name, column := range columns {
+		if column == "" {
+			continue
+		}
+
+		if err := sheetref.ValidateColumn(column); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Helper function which validates the settings depending on the item list rows.
+func (c *Config) checkItemRows() error {
+	if c.ItemList.StartNumber > c.ItemList.EndNumber {
+		return errors.New("item list start number may not be after end number")
+	}
+
+	if c.ColumnHeaders.IsSet() &&
+		c.ColumnHeaders.HeaderRow >= c.ItemList.StartNumber &&
+		c.ColumnHeaders.HeaderRow <= c.ItemList.EndNumber {
+		return errors.New("header row may not be within the item list rows")
+	}
+
+	for _, category := range c.Categories {
+		if category.StartRow == 0 && category.EndRow == 0 {
+			continue
+		}
+
+		if category.StartRow < c.ItemList.StartNumber ||
+			category.EndRow > c.ItemList.EndNumber ||
+			category.StartRow > category.EndRow {
+			return fmt.Errorf(
+				"invalid row range for category %s, needs to be within item list rows",
+				category.Name,
+			)
+		}
+	}
+
+	return nil
+}
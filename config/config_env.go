@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -25,6 +26,10 @@ const (
 	pDatabase        = "postgres_db"
 	watchRetry       = "retry_interval"
 	watchSteam       = "steam_retry_interval"
+	watchSteamMax    = "steam_retry_max_attempts"
+	watchSteamDead   = "steam_retry_deadline"
+	watchSteamBack   = "steam_retry_backoff"
+	watchOverlap     = "overlap_policy"
 	watchMaxDrop     = "max_price_drop"
 	smtpHost         = "smtp_host"
 	smtpPort         = "smtp_port"
@@ -35,9 +40,48 @@ const (
 	priceColumn      = "price_column"
 	priceTotalColumn = "price_total_column"
 	amountColumn     = "amount_column"
+	headerRow        = "header_row"
+	itemHeader       = "item_header"
+	priceHeader      = "price_header"
+	priceTotalHeader = "price_total_header"
+	amountHeader     = "amount_header"
+	rarityColumn     = "rarity_column"
+	typeColumn       = "type_column"
+	collectionColumn = "collection_column"
+	exteriorColumn   = "exterior_column"
+	categories       = "categories"
+	holdLiquidCell   = "liquid_value_cell"
+	holdLockedCell   = "locked_value_cell"
+	holdColumn       = "hold_column"
+	holdWarningDays  = "hold_expiry_warning_days"
+	priceStyle       = "price_change_style"
+	deltaColumn      = "delta_column"
+	historyTab       = "price_history_tab"
+	historyItems     = "price_history_items"
 	spreadID         = "spreadsheet_id"
+	sheetType        = "sheet_backend"
+	sheetPath        = "sheet_path"
+	sheetName        = "sheet_name"
+	googleRetries    = "google_max_retries"
+	googleRequests   = "google_requests_per_minute"
+	googleAuth       = "google_auth"
+	googleCredsEnv   = "google_credentials_env"
+	googleSecret     = "google_client_secret_file"
+	googleToken      = "google_token_file"
 	steamAPI         = "steam_api_key"
 	steamUID         = "steam_user_id_64"
+	steamAccounts    = "steam_accounts"
+	runCooldown      = "run_cooldown"
+	healthServices   = "steam_required_services"
+	healthDelayed    = "steam_treat_delayed_as_down"
+	healthSkipPrice  = "steam_skip_check_price_runs"
+	steamStatusURL   = "steam_status_url"
+	steamPriceURL    = "steam_price_url"
+	steamInvURL      = "steam_inventory_url"
+	steamTimeout     = "steam_timeout"
+	steamPriceTime   = "steam_price_timeout"
+	githubURL        = "github_release_url"
+	githubTimeout    = "github_timeout"
 )
 
 func LoadConfigFromEnv(file string) (*Config, error) {
@@ -72,6 +116,21 @@ func LoadConfigFromEnv(file string) (*Config, error) {
 		return nil, checkError(err, watchSteam)
 	}
 
+	steamRetryMaxAttempts, err := getEnvIntOptional(watchSteamMax, 0)
+	if err != nil {
+		return nil, checkError(err, watchSteamMax)
+	}
+
+	steamRetryDeadline, err := getEnvIntOptional(watchSteamDead, 0)
+	if err != nil {
+		return nil, checkError(err, watchSteamDead)
+	}
+
+	steamRetryBackoff, err := getEnvFloatOptional(watchSteamBack, 0)
+	if err != nil {
+		return nil, checkError(err, watchSteamBack)
+	}
+
 	maxPriceDrop, err := getEnvFloat(watchMaxDrop)
 	if err != nil {
 		return nil, checkError(err, watchMaxDrop)
@@ -82,11 +141,76 @@ func LoadConfigFromEnv(file string) (*Config, error) {
 		return nil, checkError(err, smtpPort)
 	}
 
-	steamUserID64, err := getEnvUint(steamUID)
+	steamUserID64, err := getEnvUintOptional(steamUID, 0)
 	if err != nil {
 		return nil, checkError(err, steamUID)
 	}
 
+	headerRowInt, err := getEnvIntOptional(headerRow, 0)
+	if err != nil {
+		return nil, checkError(err, headerRow)
+	}
+
+	holdWarningDaysInt, err := getEnvIntOptional(holdWarningDays, 0)
+	if err != nil {
+		return nil, checkError(err, holdWarningDays)
+	}
+
+	historyIncludeItems, err := getEnvBoolOptional(historyItems, false)
+	if err != nil {
+		return nil, checkError(err, historyItems)
+	}
+
+	categoryList, err := getEnvCategories(categories)
+	if err != nil {
+		return nil, err
+	}
+
+	steamAccountList, err := getEnvSteamAccounts(steamAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	treatDelayedAsDown, err := getEnvBoolOptional(healthDelayed, false)
+	if err != nil {
+		return nil, checkError(err, healthDelayed)
+	}
+
+	skipForPriceRuns, err := getEnvBoolOptional(healthSkipPrice, false)
+	if err != nil {
+		return nil, checkError(err, healthSkipPrice)
+	}
+
+	googleRetriesInt, err := getEnvIntOptional(googleRetries, 0)
+	if err != nil {
+		return nil, checkError(err, googleRetries)
+	}
+
+	googleRequestsInt, err := getEnvIntOptional(googleRequests, 0)
+	if err != nil {
+		return nil, checkError(err, googleRequests)
+	}
+
+	runCooldownInt, err := getEnvIntOptional(runCooldown, 0)
+	if err != nil {
+		return nil, checkError(err, runCooldown)
+	}
+
+	steamTimeoutInt, err := getEnvIntOptional(steamTimeout, 0)
+	if err != nil {
+		return nil, checkError(err, steamTimeout)
+	}
+
+	steamPriceTimeoutInt, err := getEnvIntOptional(steamPriceTime, 0)
+	if err != nil {
+		return nil, checkError(err, steamPriceTime)
+	}
+
+	githubTimeoutInt, err := getEnvIntOptional(githubTimeout, 0)
+	if err != nil {
+		return nil, checkError(err, githubTimeout)
+	}
+
 	return &Config{ItemList: ItemList{
 			ColumnLetter: getEnvString(itemColumnLetter),
 			StartNumber:  itemStartNumberInt,
@@ -95,6 +219,34 @@ func LoadConfigFromEnv(file string) (*Config, error) {
 			PriceColumn:      getEnvString(priceColumn),
 			PriceTotalColumn: getEnvString(priceTotalColumn),
 			AmountColumn:     getEnvString(amountColumn),
+			ColumnHeaders: ColumnHeaders{
+				HeaderRow:  headerRowInt,
+				Item:       getEnvString(itemHeader),
+				Price:      getEnvString(priceHeader),
+				PriceTotal: getEnvString(priceTotalHeader),
+				Amount:     getEnvString(amountHeader),
+			},
+			MetadataColumns: MetadataColumns{
+				Rarity:     getEnvString(rarityColumn),
+				Type:       getEnvString(typeColumn),
+				Collection: getEnvString(collectionColumn),
+				Exterior:   getEnvString(exteriorColumn),
+			},
+			Categories: categoryList,
+			TradeHolds: TradeHolds{
+				LiquidValueCell:   getEnvString(holdLiquidCell),
+				LockedValueCell:   getEnvString(holdLockedCell),
+				HoldColumn:        getEnvString(holdColumn),
+				ExpiryWarningDays: holdWarningDaysInt,
+			},
+			PriceFormatting: PriceFormatting{
+				Style:       getEnvString(priceStyle),
+				DeltaColumn: getEnvString(deltaColumn),
+			},
+			PriceHistory: PriceHistory{
+				Tab:          getEnvString(historyTab),
+				IncludeItems: historyIncludeItems,
+			},
 			OrgCells: OrgCells{
 				LastUpdatedCell: getEnvString(orgLastUpdated),
 				ErrorCell:       getEnvString(orgErrorCell),
@@ -102,18 +254,53 @@ func LoadConfigFromEnv(file string) (*Config, error) {
 				DifferenceCell:  getEnvString(orgDiffCell),
 			},
 			SpreadSheetID: getEnvString(spreadID),
+			SheetBackend: SheetBackend{
+				Type:      getEnvString(sheetType),
+				Path:      getEnvString(sheetPath),
+				SheetName: getEnvString(sheetName),
+			},
+			GoogleAPI: GoogleAPI{
+				MaxRetries:        googleRetriesInt,
+				RequestsPerMinute: googleRequestsInt,
+			},
+			GoogleAuth: GoogleAuth{
+				Type:             getEnvString(googleAuth),
+				CredentialsEnv:   getEnvString(googleCredsEnv),
+				ClientSecretFile: getEnvString(googleSecret),
+				TokenFile:        getEnvString(googleToken),
+			},
 			SteamAPIKey:   getEnvString(steamAPI),
 			SteamUserID64: steamUserID64,
+			SteamAccounts: steamAccountList,
+			RunCooldown:   runCooldownInt,
+			SteamHealth: SteamHealth{
+				RequiredServices:   getEnvList(healthServices),
+				TreatDelayedAsDown: treatDelayedAsDown,
+				SkipForPriceRuns:   skipForPriceRuns,
+			},
+			Endpoints: Endpoints{
+				SteamStatusURL:    getEnvString(steamStatusURL),
+				SteamPriceURL:     getEnvString(steamPriceURL),
+				SteamInventoryURL: getEnvString(steamInvURL),
+				SteamTimeout:      steamTimeoutInt,
+				SteamPriceTimeout: steamPriceTimeoutInt,
+				GithubReleaseURL:  getEnvString(githubURL),
+				GithubTimeout:     githubTimeoutInt,
+			},
 			WatchDog: WatchDog{
-				RetryInterval:      retryInterval,
-				SteamRetryInterval: steamRetryInterval,
-				MaxPriceDrop:       maxPriceDrop,
-				SMTPHost:           getEnvString(smtpHost),
-				SMTPPort:           smtpPortInt,
-				SMTPUser:           getEnvString(smtpUser),
-				SMTPPassword:       getEnvString(smtpPassword),
-				SMTPFrom:           getEnvString(smtpFrom),
-				SMTPTo:             getEnvString(smtpTo),
+				RetryInterval:         retryInterval,
+				SteamRetryInterval:    steamRetryInterval,
+				SteamRetryMaxAttempts: steamRetryMaxAttempts,
+				SteamRetryDeadline:    steamRetryDeadline,
+				SteamRetryBackoff:     steamRetryBackoff,
+				OverlapPolicy:         getEnvString(watchOverlap),
+				MaxPriceDrop:          maxPriceDrop,
+				SMTPHost:              getEnvString(smtpHost),
+				SMTPPort:              smtpPortInt,
+				SMTPUser:              getEnvString(smtpUser),
+				SMTPPassword:          getEnvString(smtpPassword),
+				SMTPFrom:              getEnvString(smtpFrom),
+				SMTPTo:                getEnvString(smtpTo),
 				Postgres: Postgres{
 					Host:     getEnvString(pHost),
 					Port:     postgresPort,
@@ -134,14 +321,113 @@ func getEnvInt(name string) (int, error) {
 	return strconv.Atoi(os.Getenv(strings.ToUpper(name)))
 }
 
+// Returns the fallback value if the env variable is not set.
+func getEnvIntOptional(name string, fallback int) (int, error) {
+	if getEnvString(name) == "" {
+		return fallback, nil
+	}
+	return getEnvInt(name)
+}
+
 func getEnvUint(name string) (uint64, error) {
 	return strconv.ParseUint(os.Getenv(strings.ToUpper(name)), 10, 64)
 }
 
+// Returns the fallback value if the env variable is not set.
+func getEnvUintOptional(name string, fallback uint64) (uint64, error) {
+	if getEnvString(name) == "" {
+		return fallback, nil
+	}
+	return getEnvUint(name)
+}
+
+// Returns the fallback value if the env variable is not set.
+func getEnvBoolOptional(name string, fallback bool) (bool, error) {
+	if getEnvString(name) == "" {
+		return fallback, nil
+	}
+	return strconv.ParseBool(getEnvString(name))
+}
+
+// Splits a comma seperated env variable, returns nil if it is not set.
+func getEnvList(name string) []string {
+	value := getEnvString(name)
+	if value == "" {
+		return nil
+	}
+
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		list = append(list, strings.TrimSpace(entry))
+	}
+
+	return list
+}
+
+// Parses steam accounts in the format "label:steamid64[:amount_column]", seperated by commas.
+func getEnvSteamAccounts(name string) ([]SteamAccount, error) {
+	var accounts []SteamAccount
+
+	value := getEnvString(name)
+	if value == "" {
+		return accounts, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf(
+				"malformed steam account in env key %s: %s, want label:steamid64[:amount_column]",
+				strings.ToUpper(name),
+				entry,
+			)
+		}
+
+		steamID64, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed steam id in env key %s: %s", strings.ToUpper(name), parts[1])
+		}
+
+		account := SteamAccount{Label: parts[0], SteamID64: steamID64}
+
+		if len(parts) == 3 {
+			account.AmountColumn = parts[2]
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// Parses categories from a JSON array, same format as in the config file.
+func getEnvCategories(name string) ([]Category, error) {
+	var categoryList []Category
+
+	value := getEnvString(name)
+	if value == "" {
+		return categoryList, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &categoryList); err != nil {
+		return nil, fmt.Errorf("malformed categories in env key %s: %s", strings.ToUpper(name), err.Error())
+	}
+
+	return categoryList, nil
+}
+
 func getEnvFloat(name string) (float64, error) {
 	return strconv.ParseFloat(os.Getenv(strings.ToUpper(name)), 64)
 }
 
+// Returns the fallback value if the env variable is not set.
+func getEnvFloatOptional(name string, fallback float64) (float64, error) {
+	if getEnvString(name) == "" {
+		return fallback, nil
+	}
+	return getEnvFloat(name)
+}
+
 // Helper function which checks the error for keyboards.
 func checkError(err error, name string) error {
 	if strings.Contains(err.Error(), `parsing "": invalid syntax`) {
@@ -0,0 +1,183 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/statistics"
+)
+
+// Total and difference of a category after a run.
+type CategoryTotal struct {
+	Name       string
+	Total      float64
+	Difference float64
+}
+
+var (
+	categories     []config.Category
+	categoryTotals []CategoryTotal
+)
+
+// Returns the category totals of the last run, nil if no categories are configured.
+func GetCategoryTotals() []CategoryTotal {
+	return categoryTotals
+}
+
+// Function calculates the total value per category and updates the category cells.
+func updateCategoryTotals(itemList map[string]int, totalPrices map[int]string) error {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	logging.LogInfo("Updating category totals, please wait")
+
+	rowsMap, err := getCategoryRows(itemList)
+	if err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		preRunTotal, err := getCellValue(category.TotalValueCell)
+		if err != nil {
+			return err
+		}
+
+		total := 0.00
+
+		for row := range rowsMap[category.Name] {
+			price, err := parsePrice(totalPrices[row])
+			if err != nil {
+				return err
+			}
+
+			total += price
+		}
+
+		if err := spreadsheets.WriteSingleEntryToTable(category.TotalValueCell, []interface{}{formatPrice(total)}); err != nil {
+			return err
+		}
+
+		difference := total - preRunTotal
+
+		if category.DifferenceCell != "" {
+			if err := spreadsheets.WriteSingleEntryToTable(category.DifferenceCell, []interface{}{formatPrice(difference)}); err != nil {
+				return err
+			}
+		}
+
+		logging.LogDebug(
+			fmt.Sprintf("CATEGORY %s: total %.2f, difference %.2f", category.Name, total, difference),
+		)
+
+		categoryTotals = append(categoryTotals, CategoryTotal{
+			Name:       category.Name,
+			Total:      total,
+			Difference: difference,
+		})
+	}
+
+	logging.LogSuccess("Successfully updated category totals")
+
+	return nil
+}
+
+// Helper function which maps every category name to the rows of its items.
+func getCategoryRows(itemList map[string]int) (map[string]map[int]bool, error) {
+	rowsMap := make(map[string]map[int]bool)
+
+	var metadataMap map[string][]string
+
+	for _, category := range categories {
+		rows := make(map[int]bool)
+
+		switch {
+		case category.StartRow != 0:
+			for row := category.StartRow; row <= category.EndRow; row++ {
+				rows[row] = true
+			}
+		case category.Tag != "":
+			if metadataMap == nil {
+				var err error
+
+				metadataMap, err = getItemTags()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			for item, row := range itemList {
+				for _, tag := range metadataMap[item] {
+					if strings.EqualFold(tag, category.Tag) {
+						rows[row] = true
+					}
+				}
+			}
+		case category.NamePattern != "":
+			// Validated by config.CheckConfig.
+			pattern := regexp.MustCompile(category.NamePattern)
+
+			for item, row := range itemList {
+				if pattern.MatchString(item) {
+					rows[row] = true
+				}
+			}
+		}
+
+		rowsMap[category.Name] = rows
+	}
+
+	return rowsMap, nil
+}
+
+// Helper function which returns the stored metadata values per item name.
+func getItemTags() (map[string][]string, error) {
+	metadata, err := statistics.GetItemMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	tagMap := make(map[string][]string)
+
+	for _, item := range metadata {
+		tagMap[item.ItemName] = []string{item.Rarity, item.Type, item.Collection, item.Exterior}
+	}
+
+	return tagMap, nil
+}
+
+// Helper function which reads a single price cell, empty cells count as 0.
+func getCellValue(cell string) (float64, error) {
+	values, err := spreadsheets.GetValuesForCells(cell, cell)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(values) == 0 || len(values[0]) == 0 {
+		return 0, nil
+	}
+
+	return parsePrice(fmt.Sprintf("%v", values[0][0]))
+}
+
+// Helper function which converts a sheet price like "1.234,56€" to a float, empty prices are 0.
+func parsePrice(price string) (float64, error) {
+	if price == "" {
+		return 0, nil
+	}
+
+	price = checkAndReplaceDotInPrice(price)
+	price = strings.Replace(price, "€", "", 1)
+	price = strings.Replace(price, ",", ".", 1)
+
+	return strconv.ParseFloat(price, 64)
+}
+
+// Helper function which formats a float like the sheet prices.
+func formatPrice(price float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f€", price), ".", ",", 1)
+}
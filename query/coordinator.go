@@ -0,0 +1,150 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type RunStatus int
+
+const (
+	StatusIdle RunStatus = iota
+	StatusRunning
+)
+
+func (s RunStatus) String() string {
+	switch s {
+	case StatusIdle:
+		return "idle"
+	case StatusRunning:
+		return "running"
+	default:
+		return "unknown"
+	}
+}
+
+// Decides what happens to a run triggered while another run is in progress.
+type OverlapPolicy string
+
+const (
+	OverlapDrop  OverlapPolicy = "drop"
+	OverlapQueue OverlapPolicy = "queue"
+)
+
+// Returned by RunQuery when a run is already in progress and the overlap policy drops the trigger.
+var ErrRunInProgress = errors.New("query run already in progress")
+
+// Snapshot of the current query run state.
+type RunState struct {
+	Status    RunStatus
+	Phase     string
+	Done      int
+	Total     int
+	Queued    int
+	StartedAt time.Time
+}
+
+func (r RunState) String() string {
+	if r.Status == StatusIdle {
+		return fmt.Sprintf("%s (queued: %d)", r.Status, r.Queued)
+	}
+
+	return fmt.Sprintf(
+		"%s since %s, phase: %s, progress: %d/%d (queued: %d)",
+		r.Status,
+		r.StartedAt.Format("15:04:05"),
+		r.Phase,
+		r.Done,
+		r.Total,
+		r.Queued,
+	)
+}
+
+// Serialises query runs and keeps track of their state.
+type runCoordinator struct {
+	// Held for the whole duration of a run.
+	run sync.Mutex
+	// Guards policy and state.
+	mu     sync.Mutex
+	policy OverlapPolicy
+	state  RunState
+}
+
+var coordinator = &runCoordinator{policy: OverlapDrop}
+
+// Sets the overlap policy used for runs triggered while another run is in progress.
+func SetOverlapPolicy(policy OverlapPolicy) {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+
+	coordinator.policy = policy
+}
+
+// Returns a snapshot of the current run state, safe to call from any goroutine.
+func CurrentState() RunState {
+	coordinator.mu.Lock()
+	defer coordinator.mu.Unlock()
+
+	return coordinator.state
+}
+
+// Returns true if the run waited for another run, at most one run is queued at a time.
+func (c *runCoordinator) acquire() (bool, error) {
+	queued := false
+
+	if !c.run.TryLock() {
+		c.mu.Lock()
+
+		// Further triggers are covered by the run already queued.
+		if c.policy == OverlapDrop || c.state.Queued > 0 {
+			state := c.state
+			c.mu.Unlock()
+
+			return false, fmt.Errorf("%w (%s)", ErrRunInProgress, state)
+		}
+
+		c.state.Queued++
+		c.mu.Unlock()
+
+		c.run.Lock()
+
+		c.mu.Lock()
+		c.state.Queued--
+		c.mu.Unlock()
+
+		queued = true
+	}
+
+	c.mu.Lock()
+	c.state = RunState{Status: StatusRunning, Queued: c.state.Queued, StartedAt: time.Now()}
+	c.mu.Unlock()
+
+	return queued, nil
+}
+
+func (c *runCoordinator) release() {
+	c.mu.Lock()
+	c.state = RunState{Status: StatusIdle, Queued: c.state.Queued}
+	c.mu.Unlock()
+
+	c.run.Unlock()
+}
+
+func (c *runCoordinator) setPhase(phase string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state.Phase = phase
+	c.state.Done = 0
+	c.state.Total = 0
+}
+
+func (c *runCoordinator) setProgress(done, total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state.Done = done
+	c.state.Total = total
+}
@@ -0,0 +1,160 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/tables"
+)
+
+var priceFormatting config.PriceFormatting
+
+// Function reads the prices and total prices before the run overwrites them.
+//
+// Returns nil maps if neither price formatting nor the delta column is configured.
+func getPreRunPrices() (map[int]string, map[int]string, error) {
+	if priceFormatting.Style == "" && priceFormatting.DeltaColumn == "" {
+		return nil, nil, nil
+	}
+
+	logging.LogInfo("Getting prices pre run, please wait")
+
+	prices, err := getColumnValues(priceColumnLetter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totals, err := getColumnValues(priceTotalColumnLetter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logging.LogSuccess("Successfully got prices pre run")
+
+	return prices, totals, nil
+}
+
+// Function colors the price and total cells by their change since the last run and writes the deltas.
+func updatePriceChanges(
+	itemList map[string]int,
+	priceList map[string]string,
+	preRunPrices map[int]string,
+	preRunTotals map[int]string,
+	totalPrices map[int]string,
+) error {
+	if priceFormatting.Style == "" && priceFormatting.DeltaColumn == "" {
+		return nil
+	}
+
+	logging.LogInfo("Updating price changes, please wait")
+
+	changes := make(map[string]tables.CellChange)
+	deltaMap := make(map[int]string)
+
+	for item, row := range itemList {
+		priceCell := fmt.Sprintf("%s%d", priceColumnLetter, row)
+		totalCell := fmt.Sprintf("%s%d", priceTotalColumnLetter, row)
+
+		// Clears the colors of previous runs.
+		changes[priceCell] = tables.ChangeNone
+		changes[totalCell] = tables.ChangeNone
+		deltaMap[row] = ""
+
+		if strings.Contains(item, "empty_cell") {
+			continue
+		}
+
+		delta, ok, err := getPriceDelta(preRunPrices[row], priceList[item])
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			changes[priceCell] = getCellChange(delta)
+			deltaMap[row] = formatPrice(delta)
+		}
+
+		totalDelta, ok, err := getPriceDelta(preRunTotals[row], totalPrices[row])
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			changes[totalCell] = getCellChange(totalDelta)
+		}
+	}
+
+	if priceFormatting.Style != "" {
+		formatter, ok := spreadsheets.(tables.ChangeFormatter)
+		if !ok {
+			logging.LogWarning("Sheet backend does not support formatting, skipping price formatting")
+		} else if err := formatter.FormatChanges(changes, priceFormatting.Style); err != nil {
+			return err
+		}
+	}
+
+	if priceFormatting.DeltaColumn != "" {
+		if err := spreadsheets.WriteMultipleEntriesToTable(deltaMap, withTab(priceFormatting.DeltaColumn)); err != nil {
+			return err
+		}
+	}
+
+	logging.LogSuccess("Successfully updated price changes")
+
+	return nil
+}
+
+// Helper function which reads the item rows of a column mapped by row.
+func getColumnValues(column string) (map[int]string, error) {
+	values, err := spreadsheets.GetValuesForCells(
+		fmt.Sprintf("%s%d", column, itemStartNumber),
+		fmt.Sprintf("%s%d", column, itemEndNumber),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	valueMap := make(map[int]string)
+
+	for i, row := range values {
+		if len(row) > 0 {
+			valueMap[itemStartNumber+i] = fmt.Sprintf("%v", row[0])
+		}
+	}
+
+	return valueMap, nil
+}
+
+// Helper function which returns the change of a price, false if either price is empty.
+func getPriceDelta(previous, current string) (float64, bool, error) {
+	if previous == "" || current == "" {
+		return 0, false, nil
+	}
+
+	previousPrice, err := parsePrice(previous)
+	if err != nil {
+		return 0, false, err
+	}
+
+	currentPrice, err := parsePrice(current)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Rounded to cents like the sheet prices.
+	return math.Round((currentPrice-previousPrice)*100) / 100, true, nil
+}
+
+func getCellChange(delta float64) tables.CellChange {
+	switch {
+	case delta > 0:
+		return tables.ChangeUp
+	case delta < 0:
+		return tables.ChangeDown
+	default:
+		return tables.ChangeNone
+	}
+}
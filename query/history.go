@@ -0,0 +1,173 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/sheetref"
+	"github.com/devusSs/steamquery-v2/tables"
+)
+
+// Last column of the price history header row which gets read and its number of columns.
+const (
+	priceHistoryLastColumn = "ZZ"
+	priceHistoryMaxColumns = 26 + 26*26
+)
+
+// Columns every price history row starts with, followed by the item prices if enabled.
+var priceHistoryColumns = []string{"Timestamp", "Total", "Difference"}
+
+var priceHistory config.PriceHistory
+
+// Function appends the run to the price history tab if configured.
+//
+// The header row gets written on the first run and extended by items without a column yet.
+func appendPriceHistory(
+	itemList map[string]int,
+	priceList map[string]string,
+	priceDifference float64,
+) error {
+	if priceHistory.Tab == "" {
+		return nil
+	}
+
+	logging.LogInfo("Appending run to price history, please wait")
+
+	header, err := getPriceHistoryHeader()
+	if err != nil {
+		return err
+	}
+
+	columns := append([]string{}, priceHistoryColumns...)
+
+	if priceHistory.IncludeItems {
+		var items []string
+		for item := range itemList {
+			if !strings.Contains(item, "empty_cell") {
+				items = append(items, item)
+			}
+		}
+		sort.Strings(items)
+
+		columns = append(columns, items...)
+	}
+
+	headerChanged := false
+
+	for _, column := range columns {
+		if !containsColumn(header, column) {
+			header = append(header, column)
+			headerChanged = true
+		}
+	}
+
+	if len(header) > priceHistoryMaxColumns {
+		return fmt.Errorf(
+			"price history needs %d columns but only supports up to column %s (%d columns), start a new tab or disable include items",
+			len(header),
+			priceHistoryLastColumn,
+			priceHistoryMaxColumns,
+		)
+	}
+
+	if headerChanged {
+		var headerRow []interface{}
+		for _, column := range header {
+			headerRow = append(headerRow, column)
+		}
+
+		if err := spreadsheets.WriteRange(priceHistory.GetStartCell(), [][]interface{}{headerRow}); err != nil {
+			return err
+		}
+	}
+
+	totalValue, err := getTotalValueCell()
+	if err != nil {
+		return err
+	}
+
+	// Prices are written as numbers so charts work regardless of the sheet locale.
+	var row []interface{}
+
+	for _, column := range header {
+		switch column {
+		case "Timestamp":
+			row = append(row, time.Now().Format("2006-01-02 15:04:05"))
+		case "Total":
+			row = append(row, roundPrice(totalValue))
+		case "Difference":
+			row = append(row, roundPrice(priceDifference))
+		default:
+			price, ok := priceList[column]
+			// Items which left the sheet keep their column but get no price.
+			if !ok || price == "" {
+				row = append(row, "")
+				continue
+			}
+
+			value, err := parsePrice(price)
+			if err != nil {
+				logging.LogWarning(fmt.Sprintf("Skipping price history price of %s: %s", column, err.Error()))
+				row = append(row, "")
+				continue
+			}
+
+			row = append(row, roundPrice(value))
+		}
+	}
+
+	appender, ok := spreadsheets.(tables.RowAppender)
+	if !ok {
+		return errors.New("sheet backend does not support appending the price history")
+	}
+
+	if err := appender.AppendRows(priceHistory.GetStartCell(), [][]interface{}{row}); err != nil {
+		return err
+	}
+
+	logging.LogSuccess("Successfully appended run to price history")
+
+	return nil
+}
+
+// Helper function which reads the header row of the price history tab.
+func getPriceHistoryHeader() ([]string, error) {
+	values, err := spreadsheets.GetValuesForCells(
+		priceHistory.GetStartCell(),
+		sheetref.JoinReference(priceHistory.Tab, fmt.Sprintf("%s1", priceHistoryLastColumn)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+
+	if len(values) > 0 {
+		for _, value := range values[0] {
+			header = append(header, fmt.Sprintf("%v", value))
+		}
+	}
+
+	return header, nil
+}
+
+// Helper function which rounds a price to cents.
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
+
+func containsColumn(header []string, column string) bool {
+	for _, existing := range header {
+		if existing == column {
+			return true
+		}
+	}
+
+	return false
+}
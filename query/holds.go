@@ -0,0 +1,149 @@
+package query
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/steam"
+)
+
+// Used if no expiry warning days are specified in the config.
+const defaultHoldExpiryWarningDays = 2
+
+// Value of the items which can be sold right away and of the items on hold after a run.
+type HoldValues struct {
+	Liquid float64
+	Locked float64
+}
+
+var (
+	tradeHolds config.TradeHolds
+	itemHolds  map[string]steam.ItemHold
+	holdValues *HoldValues
+)
+
+// Returns the liquid and locked value of the last run, nil if no trade holds were tracked.
+func GetHoldValues() *HoldValues {
+	return holdValues
+}
+
+// Function fetches the inventories of all accounts for the trade holds.
+//
+// Only used without the inventory sync (beta features), which already fetches the inventories.
+func fetchItemHolds() error {
+	accountMap, err := steam.GetSteamInventories(getSteamAccounts())
+	if err != nil {
+		return err
+	}
+
+	itemHolds = steam.GetItemHolds(accountMap)
+
+	return nil
+}
+
+// Function splits the total value into liquid and locked value and flags rows on hold.
+func updateTradeHolds(
+	itemList map[string]int,
+	amountList map[int]int,
+	totalPrices map[int]string,
+) error {
+	if !tradeHolds.IsSet() {
+		return nil
+	}
+
+	logging.LogInfo("Updating trade hold values, please wait")
+
+	warningDays := tradeHolds.ExpiryWarningDays
+	if warningDays == 0 {
+		warningDays = defaultHoldExpiryWarningDays
+	}
+
+	values := &HoldValues{}
+	holdMap := make(map[int]string)
+
+	for item, row := range itemList {
+		holdMap[row] = ""
+
+		rowTotal, err := parsePrice(totalPrices[row])
+		if err != nil {
+			return err
+		}
+
+		hold, ok := itemHolds[item]
+		amount := amountList[row]
+
+		if !ok || amount == 0 {
+			values.Liquid += rowTotal
+			continue
+		}
+
+		locked := minInt(hold.Locked, amount)
+		lockedValue := rowTotal * float64(locked) / float64(amount)
+
+		values.Locked += lockedValue
+		values.Liquid += rowTotal - lockedValue
+
+		holdMap[row] = formatHold(hold, locked, warningDays)
+
+		if !hold.HoldUntil.IsZero() && time.Until(hold.HoldUntil) < time.Duration(warningDays)*24*time.Hour {
+			logging.LogInfo(
+				fmt.Sprintf(
+					"Hold on %dx %s expires soon (%s)",
+					locked,
+					item,
+					hold.HoldUntil.Local().Format("2006-01-02 15:04"),
+				),
+			)
+		}
+	}
+
+	if tradeHolds.LiquidValueCell != "" {
+		if err := spreadsheets.WriteSingleEntryToTable(tradeHolds.LiquidValueCell, []interface{}{formatPrice(values.Liquid)}); err != nil {
+			return err
+		}
+	}
+
+	if tradeHolds.LockedValueCell != "" {
+		if err := spreadsheets.WriteSingleEntryToTable(tradeHolds.LockedValueCell, []interface{}{formatPrice(values.Locked)}); err != nil {
+			return err
+		}
+	}
+
+	if tradeHolds.HoldColumn != "" {
+		if err := spreadsheets.WriteMultipleEntriesToTable(holdMap, withTab(tradeHolds.HoldColumn)); err != nil {
+			return err
+		}
+	}
+
+	logging.LogDebug(fmt.Sprintf("LIQUID VALUE: %.2f ; LOCKED VALUE: %.2f", values.Liquid, values.Locked))
+
+	holdValues = values
+
+	logging.LogSuccess("Successfully updated trade hold values")
+
+	return nil
+}
+
+// Helper function which describes the hold of a row for the hold column.
+func formatHold(hold steam.ItemHold, locked, warningDays int) string {
+	if hold.HoldUntil.IsZero() {
+		return fmt.Sprintf("%dx not tradable", locked)
+	}
+
+	holdUntil := hold.HoldUntil.Local().Format("2006-01-02 15:04")
+
+	if time.Until(hold.HoldUntil) < time.Duration(warningDays)*24*time.Hour {
+		return fmt.Sprintf("%dx on hold, expires soon (%s)", locked, holdUntil)
+	}
+
+	return fmt.Sprintf("%dx on hold until %s", locked, holdUntil)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
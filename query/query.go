@@ -1,12 +1,12 @@
 package query
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,19 +15,28 @@ import (
 
 	"github.com/devusSs/steamquery-v2/config"
 	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/sheetref"
 	"github.com/devusSs/steamquery-v2/statistics"
 	"github.com/devusSs/steamquery-v2/statistics/database"
 	"github.com/devusSs/steamquery-v2/steam"
-	"github.com/devusSs/steamquery-v2/system"
 	"github.com/devusSs/steamquery-v2/tables"
 	"github.com/devusSs/steamquery-v2/types"
+	"github.com/google/uuid"
 )
 
 var (
-	usingBeta      bool
-	skipCellChecks bool
+	usingBeta       bool
+	skipCellChecks  bool
+	autoConfirmSync bool
+	// Watchdog runs can not be confirmed, nobody reads stdin.
+	unattended      bool
+	storageUnitFile string
+	skipPriceHealth bool
 
-	spreadsheets *tables.SpreadsheetService
+	spreadsheets tables.SheetStore
+
+	// Tab of the item column, columns without a tab default to it.
+	sheetTab string
 
 	itemColumnLetter string
 	itemStartNumber  int
@@ -37,34 +46,108 @@ var (
 	priceTotalColumnLetter string
 	amountColumnLetter     string
 
+	columnHeaders   config.ColumnHeaders
+	metadataColumns config.MetadataColumns
+
 	lastUpdatedCell string
 	errorCell       string
 	totalValueCell  string
 	differenceCell  string
 
-	steamAPIKey string
-	steamUser64 uint64
+	steamAPIKey   string
+	steamAccounts []config.SteamAccount
+
+	portfolioID      string
+	runCooldownTimer time.Duration
+
+	inventoryReport *statistics.InventoryReport
+)
+
+// Defaults used if the corresponding values are not specified in the config.
+const (
+	defaultRunCooldown           = 3
+	defaultSteamRetryMaxAttempts = 12
+	defaultSteamRetryDeadline    = 6 * time.Hour
+	defaultSteamRetryBackoff     = 1.0
+)
 
-	QueryRunning bool
+var (
+	// Returned by RunQuery when the last run or last error is within the cooldown.
+	ErrCooldownActive = errors.New("cooldown active")
+	// Returned by RunQuery when Steam stayed down and the retry policy gave up.
+	ErrSteamUnavailable = errors.New("steam down")
 )
 
+// Policy for retrying the Steam availability check when Steam is down.
+//
+// An interval of 0 disables retries.
+type SteamRetryPolicy struct {
+	Interval    time.Duration
+	MaxAttempts int
+	Deadline    time.Duration
+	Backoff     float64
+}
+
+// Creates a retry policy from the watchdog config and applies defaults for unset values.
+func NewSteamRetryPolicy(cfg config.WatchDog) SteamRetryPolicy {
+	policy := SteamRetryPolicy{
+		Interval:    time.Duration(cfg.SteamRetryInterval) * time.Minute,
+		MaxAttempts: cfg.SteamRetryMaxAttempts,
+		Deadline:    time.Duration(cfg.SteamRetryDeadline) * time.Minute,
+		Backoff:     cfg.SteamRetryBackoff,
+	}
+
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaultSteamRetryMaxAttempts
+	}
+
+	if policy.Deadline == 0 {
+		policy.Deadline = defaultSteamRetryDeadline
+	}
+
+	if policy.Backoff == 0 {
+		policy.Backoff = defaultSteamRetryBackoff
+	}
+
+	return policy
+}
+
+// Sets up the query and resolves the columns referenced by header.
+//
+// Returns an error if a header can not be found.
 func InitQuery(
-	service *tables.SpreadsheetService,
+	service tables.SheetStore,
 	itemList config.ItemList,
 	priceColumn string,
 	priceTotalColumn string,
 	amountColumn string,
+	headers config.ColumnHeaders,
+	metadataColumnList config.MetadataColumns,
+	categoryList []config.Category,
+	tradeHoldList config.TradeHolds,
+	priceFormat config.PriceFormatting,
+	priceHistoryTab config.PriceHistory,
 	orgCells config.OrgCells,
 	steamAPIKeyConfig string,
-	steamUserID64 uint64,
+	steamAccountList []config.SteamAccount,
+	portfolio string,
+	runCooldown int,
 	skipChecks bool,
 	betaFeatures bool,
-) {
+	confirmSync bool,
+	watchdog bool,
+	storageFile string,
+	skipPriceRunHealthCheck bool,
+) error {
 	usingBeta = betaFeatures
+	autoConfirmSync = confirmSync
+	unattended = watchdog
+	storageUnitFile = storageFile
+	skipPriceHealth = skipPriceRunHealthCheck
 
 	if skipChecks {
 		logging.LogWarning(
-			"Skip checks flag specified, skipping last updated and error cell check on sheets",
+			"Skip checks flag specified, skipping last run and last error cooldown check",
 		)
 	}
 
@@ -72,13 +155,22 @@ func InitQuery(
 
 	spreadsheets = service
 
+	sheetTab, _ = sheetref.SplitReference(itemList.ColumnLetter)
+
 	itemColumnLetter = itemList.ColumnLetter
 	itemStartNumber = itemList.StartNumber
 	itemEndNumber = itemList.EndNumber
 
-	priceColumnLetter = priceColumn
-	priceTotalColumnLetter = priceTotalColumn
-	amountColumnLetter = amountColumn
+	priceColumnLetter = withTab(priceColumn)
+	priceTotalColumnLetter = withTab(priceTotalColumn)
+	amountColumnLetter = withTab(amountColumn)
+
+	columnHeaders = headers
+	metadataColumns = metadataColumnList
+	categories = categoryList
+	tradeHolds = tradeHoldList
+	priceFormatting = priceFormat
+	priceHistory = priceHistoryTab
 
 	lastUpdatedCell = orgCells.LastUpdatedCell
 	errorCell = orgCells.ErrorCell
@@ -86,53 +178,108 @@ func InitQuery(
 	differenceCell = orgCells.DifferenceCell
 
 	steamAPIKey = steamAPIKeyConfig
-	steamUser64 = steamUserID64
-}
+	steamAccounts = steamAccountList
+
+	if runCooldown == 0 {
+		runCooldown = defaultRunCooldown
+	}
 
-func RunQuery(steamRetryInterval int) (float64, error) {
-	QueryRunning = true
+	portfolioID = portfolio
+	runCooldownTimer = time.Duration(runCooldown) * time.Minute
 
-	steamUp, err := steam.IsSteamCSGOAPIUp(steamAPIKey)
+	// Startup checks like the request limit read the item column before the first run.
+	if columnHeaders.IsSet() {
+		if err := resolveColumnHeaders(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Runs the query once, serialised with other runs according to the overlap policy.
+//
+// Returns ErrRunInProgress if the trigger got dropped because of a run in progress.
+// Queued runs skip the cooldown check since the run they waited for just finished.
+func RunQuery(retryPolicy SteamRetryPolicy) (float64, error) {
+	queued, err := coordinator.acquire()
 	if err != nil {
 		return 0, err
 	}
+	defer coordinator.release()
+
+	tables.ResetRequestStats()
+	defer logRequestStats()
 
-	if !steamUp {
-		if steamRetryInterval != 0 {
-			logging.LogInfo(fmt.Sprintf("Rerunning steamquery in %d minutes", steamRetryInterval))
-			time.Sleep(time.Duration(steamRetryInterval) * time.Minute)
-			return RunQuery(steamRetryInterval)
+	priceDifference, err := runQuery(retryPolicy, queued)
+	if err != nil && !errors.Is(err, ErrCooldownActive) {
+		if err := saveRunError(err); err != nil {
+			logging.LogError(fmt.Sprintf("STATE ERROR: %s", err.Error()))
 		}
+	}
 
-		return 0, errors.New("steam down, retry later")
+	return priceDifference, err
+}
+
+// Helper function which logs the Google Sheets requests of the run against the budget.
+func logRequestStats() {
+	stats := tables.GetRequestStats()
+	if stats.Requests == 0 {
+		return
 	}
 
-	logging.LogSuccess("Steam is up, proceeding")
+	logging.LogInfo(
+		fmt.Sprintf(
+			"Google Sheets requests: %d (peak %d/min of %d/min budget, retries: %d, quota errors: %d, throttled: %v)",
+			stats.Requests,
+			stats.PeakPerMinute,
+			tables.GetRequestBudget(),
+			stats.Retries,
+			stats.QuotaErrors,
+			stats.Throttled.Round(time.Second),
+		),
+	)
+}
+
+func runQuery(retryPolicy SteamRetryPolicy, queued bool) (float64, error) {
+	inventoryReport = nil
+	categoryTotals = nil
+	itemHolds = nil
+	holdValues = nil
 
-	if !skipCellChecks {
-		lastUpdatedString, err := getLastUpdatedCellValue()
-		if err != nil {
+	// Price only runs do not need Steam logon, the inventory sync and trade holds do.
+	if skipPriceHealth && !usingBeta && !tradeHolds.IsSet() {
+		logging.LogWarning("Skipping Steam status check for price only run")
+	} else {
+		coordinator.setPhase("checking steam status")
+
+		if err := waitForSteam(retryPolicy); err != nil {
 			return 0, err
 		}
 
-		if lastUpdatedString != "" {
-			if err := compareLastUpdatedCell(lastUpdatedString); err != nil {
-				return 0, err
-			}
-		}
+		logging.LogSuccess("Steam is up, proceeding")
+	}
 
-		lastErrorTimestamp, err := getLastErrorTimestamp()
-		if err != nil {
+	coordinator.setPhase("checking cooldown")
+
+	if queued {
+		logging.LogInfo("Skipping cooldown check for queued run")
+	} else if !skipCellChecks {
+		if err := checkRunCooldown(); err != nil {
 			return 0, err
 		}
+	}
 
-		if !lastErrorTimestamp.IsZero() {
-			if err := compareLastErrorTimestamp(lastErrorTimestamp); err != nil {
-				return 0, err
-			}
+	if columnHeaders.IsSet() {
+		coordinator.setPhase("resolving column headers")
+
+		if err := resolveColumnHeaders(); err != nil {
+			return 0, err
 		}
 	}
 
+	coordinator.setPhase("fetching items and amounts")
+
 	itemList, err := getItemNamesFromSheets()
 	if err != nil {
 		return 0, err
@@ -144,21 +291,23 @@ func RunQuery(steamRetryInterval int) (float64, error) {
 	}
 
 	if usingBeta {
-		totalSheetsMap, err := steam.GetAndCompareSteamInventory(
-			steamAPIKey,
-			steamUser64,
-			itemList,
-			amountList,
-		)
-		if err != nil {
+		coordinator.setPhase("syncing inventory")
+
+		if err := syncInventoryToSheet(itemList, amountList); err != nil {
 			return 0, err
 		}
+	}
 
-		logging.LogDebug(fmt.Sprintf("TOTAL SHEETS MAP: %v", totalSheetsMap))
+	if !usingBeta && tradeHolds.IsSet() {
+		coordinator.setPhase("fetching trade holds")
 
-		return 0, nil
+		if err := fetchItemHolds(); err != nil {
+			return 0, err
+		}
 	}
 
+	coordinator.setPhase("fetching prices")
+
 	priceMap, marketAmountMap, err := getItemMarketValues(itemList)
 	if err != nil {
 		return 0, err
@@ -169,6 +318,9 @@ func RunQuery(steamRetryInterval int) (float64, error) {
 	wg.Add(1)
 	go statistics.AnalyseVolumes(wg, time.Now(), marketAmountMap)
 
+	// All rows of a run share one timestamp so grouped charts get one data point per run.
+	runTime := time.Now()
+
 	go func() {
 		for item, price := range priceMap {
 			wg.Add(1)
@@ -180,7 +332,7 @@ func RunQuery(steamRetryInterval int) (float64, error) {
 				logging.LogError(fmt.Sprintf("STATS ERROR: %s", err.Error()))
 			}
 
-			if err := statistics.AddStatistics(&database.SteamQueryV2Values{ItemName: item, Price: convertedPrice, Volume: marketAmountMap[item], Created: time.Now()}); err != nil {
+			if err := statistics.AddStatistics(&database.SteamQueryV2Values{ItemName: item, Price: convertedPrice, Volume: marketAmountMap[item], Created: runTime}); err != nil {
 				logging.LogError(fmt.Sprintf("STATS ERROR: %s", err.Error()))
 			}
 			wg.Done()
@@ -188,6 +340,13 @@ func RunQuery(steamRetryInterval int) (float64, error) {
 		}
 	}()
 
+	coordinator.setPhase("writing prices and totals")
+
+	preRunPrices, preRunTotals, err := getPreRunPrices()
+	if err != nil {
+		return 0, err
+	}
+
 	if err := writePricesForItemMap(itemList, priceMap); err != nil {
 		return 0, err
 	}
@@ -201,6 +360,10 @@ func RunQuery(steamRetryInterval int) (float64, error) {
 		return 0, err
 	}
 
+	if err := updatePriceChanges(itemList, priceMap, preRunPrices, preRunTotals, totalPricesItemsMap); err != nil {
+		return 0, err
+	}
+
 	overallValuePreRun, err := getOverallValue()
 	if err != nil {
 		return 0, err
@@ -210,143 +373,745 @@ func RunQuery(steamRetryInterval int) (float64, error) {
 		return 0, err
 	}
 
+	if err := updateCategoryTotals(itemList, totalPricesItemsMap); err != nil {
+		return 0, err
+	}
+
+	if err := updateTradeHolds(itemList, amountList, totalPricesItemsMap); err != nil {
+		return 0, err
+	}
+
 	priceDifference, err := updateDifferenceCell(overallValuePreRun)
 	if err != nil {
 		return 0, err
 	}
 
+	if err := appendPriceHistory(itemList, priceMap, priceDifference); err != nil {
+		return 0, err
+	}
+
 	if err := writeLastUpdatedCell(); err != nil {
 		return 0, err
 	}
 
-	wg.Wait()
+	if err := saveRunSuccess(); err != nil {
+		return 0, err
+	}
 
-	QueryRunning = false
+	coordinator.setPhase("waiting for statistics")
+
+	wg.Wait()
 
 	return priceDifference, nil
 }
 
-func WriteErrorCell(err error) error {
-	logging.LogError("An error occured, writing error cell, please wait")
+// Function resolves the columns referenced by header to their letters.
+//
+// Runs on startup and on every run start so inserted columns are picked up without a restart.
+func resolveColumnHeaders() error {
+	logging.LogInfo("Resolving column headers, please wait")
+
+	columns := []struct {
+		header string
+		letter *string
+	}{
+		{columnHeaders.Item, &itemColumnLetter},
+		{columnHeaders.Price, &priceColumnLetter},
+		{columnHeaders.PriceTotal, &priceTotalColumnLetter},
+		{columnHeaders.Amount, &amountColumnLetter},
+	}
 
-	var values []interface{}
-	values = append(values, err.Error())
+	var headers []string
+	for _, column := range columns {
+		if column.header != "" {
+			headers = append(headers, column.header)
+		}
+	}
 
-	if err := spreadsheets.WriteSingleEntryToTable(errorCell, values); err != nil {
-		return err
+	resolved, err := tables.ResolveColumnHeaders(spreadsheets, sheetTab, columnHeaders.HeaderRow, headers)
+	if err != nil {
+		return fmt.Errorf("could not resolve column headers: %w", err)
 	}
 
-	logging.LogSuccess("Successfully wrote error cell")
+	for _, column := range columns {
+		if column.header == "" {
+			continue
+		}
+
+		*column.letter = sheetref.JoinReference(sheetTab, resolved[column.header])
+
+		logging.LogDebug(fmt.Sprintf("HEADER %s: column %s", column.header, *column.letter))
+	}
+
+	logging.LogSuccess("Successfully resolved column headers")
 
 	return nil
 }
 
-func WriteNoErrorCell() error {
-	logging.LogInfo("Writing error cell, please wait")
+// Helper function which prefixes a column without a tab with the tab of the item column.
+func withTab(column string) string {
+	if column == "" || strings.Contains(column, "!") {
+		return column
+	}
 
-	var values []interface{}
-	values = append(values, "No error occured.")
+	return sheetref.JoinReference(sheetTab, column)
+}
 
-	if err := spreadsheets.WriteSingleEntryToTable(errorCell, values); err != nil {
-		return err
+// Function checks the Steam status and retries according to the policy while Steam is down.
+func waitForSteam(policy SteamRetryPolicy) error {
+	startTime := time.Now()
+	interval := policy.Interval
+	runID := uuid.New()
+
+	for attempt := 1; ; attempt++ {
+		steamUp := false
+
+		status, err := steam.GetSteamStatus(steamAPIKey)
+		if err == nil {
+			steamUp = steam.IsSteamCSGOAPIUp(status)
+		}
+
+		recordSteamStatus(runID, attempt, status, steamUp, err)
+
+		if err != nil {
+			if policy.Interval == 0 {
+				return err
+			}
+			logging.LogError(fmt.Sprintf("Error fetching Steam status: %s", err.Error()))
+		}
+
+		if steamUp {
+			return nil
+		}
+
+		if policy.Interval == 0 {
+			return fmt.Errorf("%w, retry later", ErrSteamUnavailable)
+		}
+
+		if attempt >= policy.MaxAttempts {
+			return fmt.Errorf("%w, gave up after %d attempt(s)", ErrSteamUnavailable, attempt)
+		}
+
+		if time.Since(startTime)+interval > policy.Deadline {
+			return fmt.Errorf(
+				"%w, gave up after %d attempt(s) because of deadline (%v)",
+				ErrSteamUnavailable,
+				attempt,
+				policy.Deadline,
+			)
+		}
+
+		logging.LogInfo(
+			fmt.Sprintf(
+				"Steam down, rerunning status check in %v (%d/%d attempt(s))",
+				interval,
+				attempt,
+				policy.MaxAttempts,
+			),
+		)
+
+		time.Sleep(interval)
+
+		interval = time.Duration(float64(interval) * policy.Backoff)
 	}
+}
 
-	logging.LogSuccess("Successfully wrote error cell")
+// Helper function which stores a Steam status check for the status report.
+//
+// Errors are only logged since the history is not needed for the run itself.
+func recordSteamStatus(
+	runID uuid.UUID,
+	attempt int,
+	status *types.SteamAPIResponse,
+	up bool,
+	statusErr error,
+) {
+	model := &database.SteamQueryV2SteamStatus{
+		RunID:   runID,
+		Attempt: attempt,
+		Up:      up,
+		Created: time.Now(),
+	}
 
-	return nil
+	if statusErr != nil {
+		model.Error = statusErr.Error()
+	}
+
+	if status != nil {
+		model.SessionsLogon = status.Result.Services.SessionsLogon
+		model.SteamCommunity = status.Result.Services.SteamCommunity
+		model.IEconItems = status.Result.Services.IEconItems
+		model.Leaderboards = status.Result.Services.Leaderboards
+
+		loads := make(map[string]string)
+		for name, datacenter := range status.Result.Datacenters {
+			loads[name] = datacenter.Load
+		}
+
+		datacenters, err := json.Marshal(loads)
+		if err != nil {
+			logging.LogError(fmt.Sprintf("STATS ERROR: %s", err.Error()))
+		}
+
+		model.Datacenters = string(datacenters)
+	}
+
+	if err := statistics.AddSteamStatus(model); err != nil {
+		logging.LogError(fmt.Sprintf("STATS ERROR: %s", err.Error()))
+	}
 }
 
-// Helper function to calculate estimated runs / requests per day on watchdog mode.
+// Function syncs the merged inventory of all accounts to the sheet.
 //
-// Will return an error when potential requests exceed limit (20 / minute).
-func CompareRequestsDayWithLimit(retryInterval int) error {
-	// 24 hours = 1440min, 20 requests per minute is the limit for priceoverview
-	maxRequestsDayEstimate := 1440 * 20
+// Updates itemList and amountList in place so the following price run includes the changes.
+func syncInventoryToSheet(itemList map[string]int, amountList map[int]int) error {
+	storageMap, storageSnapshot, err := loadStorageUnits()
+	if err != nil {
+		return err
+	}
 
-	items, err := getItemNamesFromSheets()
+	// The run already checked and recorded the Steam status, the sync never skips that check.
+	syncMap, accountMap, err := steam.GetAndCompareSteamInventory(
+		getSteamAccounts(),
+		storageMap,
+		itemList,
+		amountList,
+	)
 	if err != nil {
 		return err
 	}
 
-	requestsPerRun := 0
+	if err := saveInventorySnapshot(accountMap, storageMap); err != nil {
+		return err
+	}
 
-	for item := range items {
-		if !strings.Contains(item, "empty_cell_") {
-			requestsPerRun++
+	if err := saveItemMetadata(accountMap); err != nil {
+		return err
+	}
+
+	itemHolds = steam.GetItemHolds(accountMap)
+
+	applied, err := applyInventorySync(itemList, amountList, syncMap)
+	if err != nil {
+		return err
+	}
+
+	// A skipped sync keeps the last import so the next run shows the same storage changes.
+	if applied && storageSnapshot != nil {
+		if err := statistics.ReplaceStorageItems(storageSnapshot); err != nil {
+			return err
 		}
+
+		logging.LogSuccess("Saved storage unit contents as last import")
 	}
 
-	runsPerDay := 24 / retryInterval
+	if err := writeAccountAmountColumns(itemList, accountMap); err != nil {
+		return err
+	}
 
-	requestsPerDay := requestsPerRun * runsPerDay
+	return writeMetadataColumns(itemList)
+}
 
-	if requestsPerDay > maxRequestsDayEstimate {
-		return errors.New("potential requests limit exceeded, please increase your retry interval")
+// Function stores the metadata (rarity, type, collection, exterior) of all fetched inventory items.
+func saveItemMetadata(accountMap map[string][]steam.InventoryItem) error {
+	metadataMap := steam.GetItemMetadata(accountMap)
+
+	var items []*database.SteamQueryV2ItemMetadata
+
+	for _, metadata := range metadataMap {
+		items = append(items, &database.SteamQueryV2ItemMetadata{
+			ItemName:   metadata.MarketHashName,
+			Rarity:     metadata.Rarity,
+			Type:       metadata.Type,
+			Collection: metadata.Collection,
+			Exterior:   metadata.Exterior,
+			Tradable:   metadata.Tradable,
+			Marketable: metadata.Marketable,
+			Updated:    time.Now(),
+		})
+	}
+
+	if err := statistics.SaveItemMetadata(items); err != nil {
+		return err
 	}
 
+	logging.LogDebug(fmt.Sprintf("Saved metadata for %d items", len(items)))
+
 	return nil
 }
 
-// Function to get the value of the last updated cell.
-func getLastUpdatedCellValue() (string, error) {
-	values, err := spreadsheets.GetValuesForCells(lastUpdatedCell, lastUpdatedCell)
+// Function writes the configured metadata columns for every item row.
+//
+// Uses the stored metadata so items which left the inventory keep their values.
+func writeMetadataColumns(itemList map[string]int) error {
+	columns := map[string]string{
+		"rarity":     metadataColumns.Rarity,
+		"type":       metadataColumns.Type,
+		"collection": metadataColumns.Collection,
+		"exterior":   metadataColumns.Exterior,
+	}
+
+	configured := false
+	for _, column := range columns {
+		if column != "" {
+			configured = true
+		}
+	}
+
+	if !configured {
+		return nil
+	}
+
+	logging.LogInfo("Writing item metadata columns, please wait")
+
+	metadata, err := statistics.GetItemMetadata()
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	// This might error on some IDEs depending on their module / import management.
-	//
-	// The error can be safely ignored, this will work anyway.
-	for i := 0; i < len(values.Values); i++ {
-		if fmt.Sprintf("%v", values.Values[i]) != "" {
-			return strings.Replace(
-				strings.Replace(fmt.Sprintf("%v", values.Values[i]), "[", "", 1),
-				"]",
-				"",
-				1,
-			), nil
+	metadataMap := make(map[string]*database.SteamQueryV2ItemMetadata)
+	for _, item := range metadata {
+		metadataMap[item.ItemName] = item
+	}
+
+	for field, column := range columns {
+		if column == "" {
+			continue
+		}
+
+		valueMap := make(map[int]string)
+
+		for item, row := range itemList {
+			valueMap[row] = ""
+
+			if itemMetadata, ok := metadataMap[item]; ok {
+				valueMap[row] = statistics.MetadataValue(itemMetadata, field)
+			}
+		}
+
+		if err := spreadsheets.WriteMultipleEntriesToTable(valueMap, withTab(column)); err != nil {
+			return err
 		}
 	}
 
-	return "", err
+	logging.LogSuccess("Successfully wrote item metadata columns")
+
+	return nil
 }
 
-// Function to compare the last updated cell to current time and exit if less than 5 minutes ago.
-func compareLastUpdatedCell(lastUpdated string) error {
-	lastUpdated = strings.Replace(lastUpdated, " CEST", "", 1)
+// Returns the inventory changes since the last snapshot, nil if the last run fetched no inventory.
+func GetInventoryReport() *statistics.InventoryReport {
+	return inventoryReport
+}
 
-	location, err := time.LoadLocation("Europe/Berlin")
-	if err != nil {
+// Function stores the fetched inventories and storage units as a snapshot and compares it to the last one.
+func saveInventorySnapshot(
+	accountMap map[string][]steam.InventoryItem,
+	storageMap map[string]int,
+) error {
+	logging.LogInfo("Saving inventory snapshot, please wait")
+
+	var snapshotItems []*database.SteamQueryV2InventoryItem
+
+	for account, items := range accountMap {
+		for _, item := range items {
+			snapshotItems = append(snapshotItems, &database.SteamQueryV2InventoryItem{
+				Account:  account,
+				AssetID:  item.AssetID,
+				ItemName: item.MarketHashName,
+				Count:    item.Amount,
+			})
+		}
+	}
+
+	for item, count := range storageMap {
+		snapshotItems = append(snapshotItems, &database.SteamQueryV2InventoryItem{
+			Account:  statistics.StorageAccount,
+			ItemName: item,
+			Count:    count,
+		})
+	}
+
+	if err := statistics.AddInventorySnapshot(time.Now(), snapshotItems); err != nil {
 		return err
 	}
 
-	timeObject, err := time.ParseInLocation("2006-01-02 15:04:05", lastUpdated, location)
+	report, err := statistics.CompareLatestInventorySnapshots()
 	if err != nil {
 		return err
 	}
 
-	systemTimeCESTLoc, err := time.LoadLocation("Europe/Berlin")
+	if report == nil {
+		logging.LogSuccess("Saved first inventory snapshot, no changes to report yet")
+		return nil
+	}
+
+	logging.LogDebug(fmt.Sprintf("ACQUIRED: %v", report.Acquired))
+	logging.LogDebug(fmt.Sprintf("SOLD: %v", report.Sold))
+	logging.LogDebug(fmt.Sprintf("MOVED TO STORAGE: %v", report.MovedToStorage))
+	logging.LogDebug(fmt.Sprintf("MOVED FROM STORAGE: %v", report.MovedFromStorage))
+
+	inventoryReport = report
+
+	logging.LogSuccess(
+		fmt.Sprintf(
+			"Saved inventory snapshot (%d acquired, %d sold, %d moved to storage, %d moved from storage)",
+			len(report.Acquired),
+			len(report.Sold),
+			len(report.MovedToStorage),
+			len(report.MovedFromStorage),
+		),
+	)
+
+	return nil
+}
+
+// Function loads the storage unit contents and compares them to the last import in the local database.
+//
+// Without a storage unit file the last import is used. A changed import is returned as snapshot,
+// it replaces the last import once the sync got applied.
+func loadStorageUnits() (map[string]int, []*database.SteamQueryV2StorageItem, error) {
+	snapshot, err := statistics.GetStorageItems()
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	snapshotMap := make(map[string]int)
+	for _, item := range snapshot {
+		snapshotMap[getStorageKey(item.ItemName, item.Unit)] += item.Count
+	}
+
+	if storageUnitFile == "" {
+		if len(snapshot) > 0 {
+			logging.LogInfo(
+				fmt.Sprintf(
+					"Using storage unit contents imported %s",
+					snapshot[0].Created.Local().Format("2006-01-02 15:04:05"),
+				),
+			)
+		}
+
+		var storageItems []steam.StorageItem
+		for _, item := range snapshot {
+			storageItems = append(
+				storageItems,
+				steam.StorageItem{Name: item.ItemName, Count: item.Count, Unit: item.Unit},
+			)
+		}
+
+		return steam.CountStorageItems(storageItems), nil, nil
 	}
 
-	systemTimeCEST := time.Now().Local().In(systemTimeCESTLoc)
+	logging.LogInfo(fmt.Sprintf("Importing storage unit file %s, please wait", storageUnitFile))
 
-	logging.LogDebug(fmt.Sprintf("LAST UPDATED: %s", lastUpdated))
-	logging.LogDebug(fmt.Sprintf("SYSTEM TIME CEST: %s", systemTimeCEST))
+	storageItems, err := steam.LoadStorageUnitFile(storageUnitFile)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if systemTimeCEST.Sub(timeObject) < 3*time.Minute {
-		logging.LogDebug(fmt.Sprintf("TIME DIFF: %v", systemTimeCEST.Sub(timeObject)))
+	importMap := make(map[string]int)
+	for _, item := range storageItems {
+		importMap[getStorageKey(item.Name, item.Unit)] += item.Count
+	}
 
-		leftOverTime := time.Until(timeObject.Add(3 * time.Minute)).Seconds()
+	var changes []string
 
-		logging.LogDebug(fmt.Sprintf("LEFTOVER TIME: %.2f second(s)", leftOverTime))
+	for key, count := range importMap {
+		if snapshotMap[key] != count {
+			changes = append(changes, fmt.Sprintf("%s %d -> %d", key, snapshotMap[key], count))
+		}
+	}
 
-		return fmt.Errorf(
-			"last run has been less than 3 minutes ago, please wait %.2f second(s)",
-			leftOverTime,
+	for key, count := range snapshotMap {
+		if _, ok := importMap[key]; !ok {
+			changes = append(changes, fmt.Sprintf("%s %d -> 0", key, count))
+		}
+	}
+
+	if len(changes) == 0 {
+		logging.LogSuccess("Storage unit contents unchanged since last import")
+		return steam.CountStorageItems(storageItems), nil, nil
+	}
+
+	sort.Strings(changes)
+
+	for _, change := range changes {
+		logging.LogInfo(fmt.Sprintf("Storage unit change since last import: %s", change))
+	}
+
+	now := time.Now()
+
+	var newSnapshot []*database.SteamQueryV2StorageItem
+	for _, item := range storageItems {
+		newSnapshot = append(newSnapshot, &database.SteamQueryV2StorageItem{
+			ItemName: item.Name,
+			Count:    item.Count,
+			Unit:     item.Unit,
+			Created:  now,
+		})
+	}
+
+	logging.LogSuccess(
+		fmt.Sprintf("Successfully imported storage unit file (%d change(s))", len(changes)),
+	)
+
+	return steam.CountStorageItems(storageItems), newSnapshot, nil
+}
+
+// Helper function which labels an item by its storage unit, items without unit use the name only.
+func getStorageKey(name, unit string) string {
+	if unit == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s (%s)", name, unit)
+}
+
+// Function writes new inventory items into free rows and raises amounts where the inventory has more.
+//
+// Returns false if the changes were skipped or cancelled.
+func applyInventorySync(
+	itemList map[string]int,
+	amountList map[int]int,
+	syncMap map[string]int,
+) (bool, error) {
+	if len(syncMap) == 0 {
+		logging.LogSuccess("Sheet already matches Steam inventory, nothing to sync")
+		return true, nil
+	}
+
+	// Trailing empty rows are not returned by the sheet, so every row without an item is free.
+	usedRows := make(map[int]bool)
+	for item, row := range itemList {
+		if !strings.Contains(item, "empty_cell_") {
+			usedRows[row] = true
+		}
+	}
+
+	var freeRows []int
+	for row := itemStartNumber; row <= itemEndNumber; row++ {
+		if !usedRows[row] {
+			freeRows = append(freeRows, row)
+		}
+	}
+
+	var newItems []string
+	var updatedItems []string
+	for item := range syncMap {
+		if _, ok := itemList[item]; ok {
+			updatedItems = append(updatedItems, item)
+		} else {
+			newItems = append(newItems, item)
+		}
+	}
+	sort.Strings(newItems)
+	sort.Strings(updatedItems)
+
+	if len(newItems) > len(freeRows) {
+		return false, fmt.Errorf(
+			"not enough free rows in item range for %d new item(s), only %d free row(s)",
+			len(newItems),
+			len(freeRows),
+		)
+	}
+
+	newItemRows := make(map[string]int)
+	for i, item := range newItems {
+		newItemRows[item] = freeRows[i]
+	}
+
+	fmt.Println("")
+	fmt.Println("Following changes will be written to your sheet:")
+	for _, item := range newItems {
+		fmt.Printf(
+			"+ %s%d: %s (amount %d)\n",
+			itemColumnLetter,
+			newItemRows[item],
+			item,
+			syncMap[item],
+		)
+	}
+	for _, item := range updatedItems {
+		row := itemList[item]
+		fmt.Printf(
+			"~ %s%d: %s (amount %d -> %d)\n",
+			amountColumnLetter,
+			row,
+			item,
+			amountList[row],
+			syncMap[item],
 		)
 	}
+	fmt.Println("")
+
+	if !autoConfirmSync && unattended {
+		logging.LogWarning("Skipping inventory sync in watchdog mode, use the -yes flag to apply the changes")
+		return false, nil
+	}
+
+	if !autoConfirmSync {
+		fmt.Println("Do you want to apply these changes (y/n)?")
+		fmt.Print("-> ")
+
+		reader := bufio.NewReader(os.Stdin)
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			logging.LogWarning(
+				fmt.Sprintf("Could not read confirmation, skipping inventory sync: %s", err.Error()),
+			)
+			return false, nil
+		}
+
+		if strings.TrimSpace(text) != "y" {
+			logging.LogWarning("Inventory sync cancelled, proceeding without changes")
+			return false, nil
+		}
+	}
+
+	logging.LogInfo("Writing inventory changes to sheets, please wait")
+
+	for _, item := range newItems {
+		row := newItemRows[item]
+
+		if err := spreadsheets.WriteSingleEntryToTable(fmt.Sprintf("%s%d", itemColumnLetter, row), []interface{}{item}); err != nil {
+			return false, err
+		}
+
+		if err := spreadsheets.WriteSingleEntryToTable(fmt.Sprintf("%s%d", amountColumnLetter, row), []interface{}{syncMap[item]}); err != nil {
+			return false, err
+		}
+
+		delete(itemList, fmt.Sprintf("empty_cell_%d", row))
+		itemList[item] = row
+		amountList[row] = syncMap[item]
+	}
+
+	for _, item := range updatedItems {
+		row := itemList[item]
+
+		if err := spreadsheets.WriteSingleEntryToTable(fmt.Sprintf("%s%d", amountColumnLetter, row), []interface{}{syncMap[item]}); err != nil {
+			return false, err
+		}
+
+		amountList[row] = syncMap[item]
+	}
+
+	logging.LogSuccess(
+		fmt.Sprintf(
+			"Successfully synced inventory (%d new item(s), %d updated amount(s))",
+			len(newItems),
+			len(updatedItems),
+		),
+	)
+
+	return true, nil
+}
+
+// Helper function which converts the configured Steam accounts for the steam package.
+func getSteamAccounts() []steam.Account {
+	var accounts []steam.Account
+	for _, account := range steamAccounts {
+		accounts = append(accounts, steam.Account{Label: account.Label, SteamID64: account.SteamID64})
+	}
+
+	return accounts
+}
+
+// Function writes the inventory count per account to the accounts' amount columns if configured.
+func writeAccountAmountColumns(
+	itemList map[string]int,
+	accountMap map[string][]steam.InventoryItem,
+) error {
+	for _, account := range steamAccounts {
+		if account.AmountColumn == "" {
+			continue
+		}
+
+		logging.LogInfo(
+			fmt.Sprintf("Writing amounts for account %s, please wait", account.Label),
+		)
+
+		accountCounts := steam.CountInventoryItems(accountMap[account.Label])
+		amountMap := make(map[int]string)
+
+		for item, row := range itemList {
+			if strings.Contains(item, "empty_cell_") {
+				amountMap[row] = ""
+				continue
+			}
+
+			amountMap[row] = strconv.Itoa(accountCounts[item])
+		}
+
+		if err := spreadsheets.WriteMultipleEntriesToTable(amountMap, withTab(account.AmountColumn)); err != nil {
+			return err
+		}
+
+		logging.LogSuccess(
+			fmt.Sprintf("Successfully wrote amounts for account %s", account.Label),
+		)
+	}
+
+	return nil
+}
+
+func WriteErrorCell(err error) error {
+	logging.LogError("An error occured, writing error cell, please wait")
+
+	var values []interface{}
+	values = append(values, err.Error())
+
+	if err := spreadsheets.WriteSingleEntryToTable(errorCell, values); err != nil {
+		return err
+	}
+
+	logging.LogSuccess("Successfully wrote error cell")
+
+	return nil
+}
+
+func WriteNoErrorCell() error {
+	logging.LogInfo("Writing error cell, please wait")
+
+	var values []interface{}
+	values = append(values, "No error occured.")
+
+	if err := spreadsheets.WriteSingleEntryToTable(errorCell, values); err != nil {
+		return err
+	}
+
+	logging.LogSuccess("Successfully wrote error cell")
+
+	return nil
+}
+
+// Helper function to calculate estimated runs / requests per day on watchdog mode.
+//
+// Will return an error when potential requests exceed limit (20 / minute).
+func CompareRequestsDayWithLimit(retryInterval int) error {
+	// 24 hours = 1440min, 20 requests per minute is the limit for priceoverview
+	maxRequestsDayEstimate := 1440 * 20
+
+	items, err := getItemNamesFromSheets()
+	if err != nil {
+		return err
+	}
+
+	requestsPerRun := 0
+
+	for item := range items {
+		if !strings.Contains(item, "empty_cell_") {
+			requestsPerRun++
+		}
+	}
+
+	runsPerDay := 24 / retryInterval
+
+	requestsPerDay := requestsPerRun * runsPerDay
+
+	if requestsPerDay > maxRequestsDayEstimate {
+		return errors.New("potential requests limit exceeded, please increase your retry interval")
+	}
 
 	return nil
 }
@@ -369,8 +1134,8 @@ func getItemNamesFromSheets() (map[string]int, error) {
 	// Might throw an error on some IDEs depending on their type handling / module management.
 	//
 	// The error may be ignored since the code works fine.
-	for i := 0; i < len(values.Values); i++ {
-		value := strings.Replace(fmt.Sprintf("%v", values.Values[i]), "[", "", 1)
+	for i := 0; i < len(values); i++ {
+		value := strings.Replace(fmt.Sprintf("%v", values[i]), "[", "", 1)
 		value = strings.Replace(value, "]", "", 1)
 		if value != "" {
 			returnMap[value] = cellNumber
@@ -398,7 +1163,6 @@ func getItemMarketValues(items map[string]int) (map[string]string, map[string]in
 
 	logging.LogWarning("Please DO NOT use Steam anywhere on your network for that time")
 
-	httpClient := http.Client{Timeout: 3 * time.Second}
 	priceMap := make(map[string]string)
 	volumeMap := make(map[string]int)
 	getCount := 0
@@ -438,32 +1202,13 @@ func getItemMarketValues(items map[string]int) (map[string]string, map[string]in
 
 		logging.LogDebug(fmt.Sprintf("Fetching price for \t\t%s", item))
 
-		u := "https://steamcommunity.com/market/priceoverview/?" + url.Values{
-			"appid":            {strconv.FormatUint(730, 10)},
-			"country":          {"EN"},
-			"currency":         {"3"},
-			"market_hash_name": {item},
-		}.Encode()
-
-		req, err := http.NewRequest(http.MethodGet, u, nil)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		req.Header.Set("User-Agent", system.GetUserAgentHeaderFromOS())
-
-		res, err := httpClient.Do(req)
+		itemMarketResponse, err := steam.GetItemMarketPrice(item)
 		if err != nil {
-			return nil, nil, err
-		}
-		defer res.Body.Close()
-
-		if res.StatusCode != http.StatusOK {
-			if res.StatusCode == http.StatusTooManyRequests {
+			if errors.Is(err, steam.ErrTooManyRequests) {
 				logging.LogError("Got timeouted by Steam, wait at least 1 minute or change IP")
 			}
 
-			if res.StatusCode == http.StatusInternalServerError {
+			if errors.Is(err, steam.ErrItemNotFound) {
 				logging.LogError(
 					fmt.Sprintf("Could not find item on Steam community market: %s", item),
 				)
@@ -476,26 +1221,11 @@ func getItemMarketValues(items map[string]int) (map[string]string, map[string]in
 
 				getCount++
 
+				coordinator.setProgress(itemsFetched, actualItemLen)
+
 				continue
 			}
 
-			return nil, nil, fmt.Errorf(
-				"unwanted Steam response: %s (code: %d)",
-				res.Status,
-				res.StatusCode,
-			)
-		}
-
-		body, err := io.ReadAll(res.Body)
-		if err != nil {
-			return nil, nil, err
-		}
-
-		system.BytesUsed += len(body)
-
-		var itemMarketResponse types.SteamItemResponse
-
-		if err := json.Unmarshal(body, &itemMarketResponse); err != nil {
 			return nil, nil, err
 		}
 
@@ -505,6 +1235,7 @@ func getItemMarketValues(items map[string]int) (map[string]string, map[string]in
 			priceMap[item] = "0,00€"
 			getCount++
 			itemsFetched++
+			coordinator.setProgress(itemsFetched, actualItemLen)
 			logging.LogDebug(fmt.Sprintf("Done fetching price for: \t%s", item))
 			continue
 		}
@@ -522,6 +1253,8 @@ func getItemMarketValues(items map[string]int) (map[string]string, map[string]in
 
 		itemsFetched++
 
+		coordinator.setProgress(itemsFetched, actualItemLen)
+
 		logging.LogDebug(
 			fmt.Sprintf(
 				"Done fetching price for: \t%s (price: %s)",
@@ -589,15 +1322,15 @@ func getItemAmountCells() (map[int]int, error) {
 	currentCell := startCell
 
 	// If user leaves amount fields empty return an error.
-	if len(values.Values) == 0 {
+	if len(values) == 0 {
 		return nil, errors.New("did not specify any amounts in sheets")
 	}
 
 	// Might throw an error on some IDEs depending on their type handling / module management.
 	//
 	// The error may be ignored since the code works fine.
-	for i := 0; i < len(values.Values); i++ {
-		value := strings.Replace(fmt.Sprintf("%v", values.Values[i]), "[", "", 1)
+	for i := 0; i < len(values); i++ {
+		value := strings.Replace(fmt.Sprintf("%v", values[i]), "[", "", 1)
 		value = strings.Replace(value, "]", "", 1)
 
 		if value == "" {
@@ -685,8 +1418,8 @@ func fetchPricePerItem() (map[int]string, error) {
 
 	currentCell := itemStartNumber
 
-	for i := 0; i < len(values.Values); i++ {
-		value := strings.Replace(fmt.Sprintf("%v", values.Values[i]), "[", "", 1)
+	for i := 0; i < len(values); i++ {
+		value := strings.Replace(fmt.Sprintf("%v", values[i]), "[", "", 1)
 		value = strings.Replace(value, "]", "", 1)
 
 		returnMap[currentCell] = value
@@ -723,14 +1456,14 @@ func getOverallValue() (string, error) {
 
 	value := ""
 
-	if len(values.Values) == 0 {
+	if len(values) == 0 {
 		value = "0,00€"
 		logging.LogSuccess("Successfully fetched initial overall value pre run")
 		return value, nil
 	}
 
-	for i := 0; i < len(values.Values); i++ {
-		value = strings.Replace(fmt.Sprintf("%v", values.Values[i]), "[", "", 1)
+	for i := 0; i < len(values); i++ {
+		value = strings.Replace(fmt.Sprintf("%v", values[i]), "[", "", 1)
 		value = strings.Replace(value, "]", "", 1)
 	}
 
@@ -823,12 +1556,12 @@ func getTotalValueCell() (float64, error) {
 	var totalValueStr string
 	var totalValue float64
 
-	if len(values.Values) == 0 {
+	if len(values) == 0 {
 		totalValueStr = "0,00€"
 	}
 
-	for i := 0; i < len(values.Values); i++ {
-		values := fmt.Sprintf("%v", values.Values[i])
+	for i := 0; i < len(values); i++ {
+		values := fmt.Sprintf("%v", values[i])
 		values = checkAndReplaceDotInPrice(values)
 		totalValueStr = strings.Replace(fmt.Sprintf("%v", values), "[", "", 1)
 		totalValueStr = strings.Replace(totalValueStr, "]", "", 1)
@@ -872,71 +1605,63 @@ func checkAndReplaceDotInPrice(price string) string {
 	return price
 }
 
-// Helper function which queries the last error timestamp and returns it for analysis.
-func getLastErrorTimestamp() (time.Time, error) {
-	logging.LogInfo("Getting last error timestamp cell, please wait")
+// Helper function which checks the local run state against the configured cooldown.
+//
+// The last updated and error cells on sheets are for display only and not read here.
+func checkRunCooldown() error {
+	logging.LogInfo("Checking last run and last error cooldown, please wait")
 
-	values, err := spreadsheets.GetValuesForCells(errorCell, errorCell)
+	state, err := statistics.GetRunState(portfolioID)
 	if err != nil {
-		return time.Time{}, err
-	}
-
-	// Error cell will be empty on first run, handle this event.
-	if len(values.Values) == 0 {
-		logging.LogSuccess("First run, no error timestamp, proceeding")
-		return time.Time{}, nil
+		return err
 	}
 
-	var errorTimestamp time.Time
-
-	// Might error depending on IDE's module / import management.
-	//
-	// Error may be ignored safely since everything works.
-	for i := 0; i < len(values.Values); i++ {
-		values := strings.Replace(fmt.Sprintf("%v", values.Values[i]), "[", "", 1)
-		values = strings.Replace(values, "]", "", 1)
-
-		if values == "No error occured." {
-			logging.LogSuccess("No error occured on last run, proceeding")
-			return time.Time{}, nil
-		}
-
-		tsSplit := strings.Split(values, "TS:")
-		ts := strings.Replace(tsSplit[1], ")", "", 1)
+	logging.LogDebug(fmt.Sprintf("LAST RUN: %v ; LAST ERROR: %v", state.LastRun, state.LastError))
 
-		// Convert the ts object to an actual time.Time object.
-		timeObj, err := time.Parse("2006-01-02 15:04:05 MST", strings.TrimSpace(ts))
-		if err != nil {
-			return time.Time{}, err
-		}
+	if !state.LastRun.IsZero() && time.Since(state.LastRun) < runCooldownTimer {
+		return fmt.Errorf(
+			"%w: last run has been less than %v ago, please wait %.2f second(s)",
+			ErrCooldownActive,
+			runCooldownTimer,
+			time.Until(state.LastRun.Add(runCooldownTimer)).Seconds(),
+		)
+	}
 
-		errorTimestamp = timeObj
+	if !state.LastError.IsZero() && time.Since(state.LastError) < runCooldownTimer {
+		return fmt.Errorf(
+			"%w: last error has been less than %v ago, please wait %.2f second(s)",
+			ErrCooldownActive,
+			runCooldownTimer,
+			time.Until(state.LastError.Add(runCooldownTimer)).Seconds(),
+		)
 	}
 
-	logging.LogSuccess("Successfully got last error timestamp")
+	logging.LogSuccess("Cooldown passed, proceeding")
 
-	return errorTimestamp, nil
+	return nil
 }
 
-// Helper function which compares last error timestamp to current time.
-func compareLastErrorTimestamp(errorTS time.Time) error {
-	logging.LogDebug(fmt.Sprintf("ERROR TS: %v", errorTS))
-
-	systemTimeCESTLoc, err := time.LoadLocation("Europe/Berlin")
+// Helper function which stores the last successful run in the local run state.
+func saveRunSuccess() error {
+	state, err := statistics.GetRunState(portfolioID)
 	if err != nil {
 		return err
 	}
 
-	systemTimeCEST := time.Now().Local().In(systemTimeCESTLoc)
+	state.LastRun = time.Now()
 
-	if systemTimeCEST.Sub(errorTS) < 3*time.Minute {
-		leftOverTime := time.Until(errorTS.Add(3 * time.Minute)).Seconds()
+	return statistics.SaveRunState(state)
+}
 
-		return fmt.Errorf(
-			"last error has been less than 3 minutes ago, please wait %.2f second(s)",
-			leftOverTime,
-		)
+// Helper function which stores the last error in the local run state.
+func saveRunError(runErr error) error {
+	state, err := statistics.GetRunState(portfolioID)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	state.LastError = time.Now()
+	state.LastErrorMessage = runErr.Error()
+
+	return statistics.SaveRunState(state)
 }
@@ -0,0 +1,172 @@
+// Package sheetref parses and validates A1 references like "'My Tab'!B6:B28" and named ranges.
+//
+// It has no dependencies so the config and the sheet stores can both use it.
+package sheetref
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	cellPattern       = regexp.MustCompile(`^[A-Za-z]{1,3}[0-9]+$`)
+	columnPattern     = regexp.MustCompile(`^[A-Za-z]{1,3}$`)
+	namedRangePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// Splits a reference like "'My Tab'!B6" into its unquoted tab name and the rest.
+//
+// References without a tab return an empty tab name.
+func SplitReference(ref string) (string, string) {
+	idx := strings.LastIndex(ref, "!")
+	if idx == -1 {
+		return "", ref
+	}
+
+	tab := ref[:idx]
+
+	if len(tab) >= 2 && strings.HasPrefix(tab, "'") && strings.HasSuffix(tab, "'") {
+		tab = strings.ReplaceAll(tab[1:len(tab)-1], "''", "'")
+	}
+
+	return tab, ref[idx+1:]
+}
+
+// Quotes a tab name for A1 notation, quotes inside the name are escaped by doubling them.
+func QuoteTab(tab string) string {
+	return "'" + strings.ReplaceAll(tab, "'", "''") + "'"
+}
+
+// Prefixes a cell, range or column with the quoted tab, an empty tab returns the reference as is.
+func JoinReference(tab, ref string) string {
+	if tab == "" {
+		return ref
+	}
+
+	return QuoteTab(tab) + "!" + ref
+}
+
+// Returns true if the reference is a named range instead of a cell, range or column.
+//
+// Like Google Sheets names looking like a cell or column (e.g. "B6" or "AB") are not allowed.
+func IsNamedRange(ref string) bool {
+	if strings.Contains(ref, "!") || strings.Contains(ref, ":") {
+		return false
+	}
+
+	return !cellPattern.MatchString(ref) &&
+		!columnPattern.MatchString(ref) &&
+		namedRangePattern.MatchString(ref)
+}
+
+// Validates a cell or range with optional tab, or a named range.
+func ValidateReference(ref string) error {
+	tab, rest := SplitReference(ref)
+
+	if strings.Contains(ref, "!") && tab == "" {
+		return fmt.Errorf("invalid reference: %s, missing tab name", ref)
+	}
+
+	if tab == "" && IsNamedRange(rest) {
+		return nil
+	}
+
+	if _, _, _, _, err := ParseRange(rest); err != nil {
+		return fmt.Errorf("invalid reference: %s, want A1, Tab!A1 or a named range", ref)
+	}
+
+	return nil
+}
+
+// Validates a column letter with optional tab like "Tab!B".
+func ValidateColumn(ref string) error {
+	tab, rest := SplitReference(ref)
+
+	if strings.Contains(ref, "!") && tab == "" {
+		return fmt.Errorf("invalid column: %s, missing tab name", ref)
+	}
+
+	if !columnPattern.MatchString(rest) {
+		return fmt.Errorf("invalid column: %s, want a column letter like B or Tab!B", ref)
+	}
+
+	return nil
+}
+
+// Splits a single column range like "'Tab'!B6:B28" into the column with tab and its first and last row.
+func ParseColumnRange(ref string) (string, int, int, error) {
+	tab, cellRange := SplitReference(ref)
+
+	startColumn, startRow, endColumn, endRow, err := ParseRange(cellRange)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	if startColumn != endColumn {
+		return "", 0, 0, fmt.Errorf("range %s needs to cover a single column", ref)
+	}
+
+	return JoinReference(tab, ColumnLetter(startColumn)), startRow, endRow, nil
+}
+
+// Splits a cell like "AB12" into its column (zero based) and row.
+func ParseCell(cell string) (int, int, error) {
+	cell = strings.ToUpper(strings.TrimSpace(cell))
+
+	i := 0
+	column := 0
+
+	for i < len(cell) && cell[i] >= 'A' && cell[i] <= 'Z' {
+		column = column*26 + int(cell[i]-'A'+1)
+		i++
+	}
+
+	if i == 0 || i == len(cell) {
+		return 0, 0, fmt.Errorf("invalid cell: %s, want A1 notation", cell)
+	}
+
+	row, err := strconv.Atoi(cell[i:])
+	if err != nil || row < 1 {
+		return 0, 0, fmt.Errorf("invalid cell: %s, want A1 notation", cell)
+	}
+
+	return column - 1, row, nil
+}
+
+// Splits a range like "B6:B28" into its columns and rows, single cells are allowed.
+func ParseRange(cellRange string) (int, int, int, int, error) {
+	startCell, endCell, found := strings.Cut(cellRange, ":")
+	if !found {
+		endCell = startCell
+	}
+
+	startColumn, startRow, err := ParseCell(startCell)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	endColumn, endRow, err := ParseCell(endCell)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	if startColumn > endColumn || startRow > endRow {
+		return 0, 0, 0, 0, fmt.Errorf("invalid range: %s", cellRange)
+	}
+
+	return startColumn, startRow, endColumn, endRow, nil
+}
+
+// Converts a zero based column index to its letters.
+func ColumnLetter(column int) string {
+	letters := ""
+
+	for column >= 0 {
+		letters = string(rune('A'+column%26)) + letters
+		column = column/26 - 1
+	}
+
+	return letters
+}
@@ -20,6 +20,17 @@ type Service interface {
 	GetValuesByDate(time.Time, time.Time) ([]*SteamQueryV2Values, error)
 	GetValuesByItemName(string) ([]*SteamQueryV2Values, error)
 	GetValuesByItemNameAndDate(string, time.Time, time.Time) ([]*SteamQueryV2Values, error)
+	GetRunState(string) (*SteamQueryV2RunState, error)
+	SaveRunState(*SteamQueryV2RunState) error
+	GetStorageItems() ([]*SteamQueryV2StorageItem, error)
+	ReplaceStorageItems([]*SteamQueryV2StorageItem) error
+	AddInventorySnapshot(*SteamQueryV2InventorySnapshot, []*SteamQueryV2InventoryItem) error
+	GetLatestInventorySnapshots(int) ([]*SteamQueryV2InventorySnapshot, error)
+	GetInventoryItems(uuid.UUID) ([]*SteamQueryV2InventoryItem, error)
+	SaveItemMetadata([]*SteamQueryV2ItemMetadata) error
+	GetItemMetadata() ([]*SteamQueryV2ItemMetadata, error)
+	AddSteamStatus(*SteamQueryV2SteamStatus) error
+	GetSteamStatusByDate(time.Time, time.Time) ([]*SteamQueryV2SteamStatus, error)
 }
 
 type SteamQueryV2Values struct {
@@ -31,13 +42,103 @@ type SteamQueryV2Values struct {
 	Created  time.Time
 }
 
+// Last run and last error per portfolio (spreadsheet), used for the run cooldown.
+type SteamQueryV2RunState struct {
+	Portfolio string `gorm:"primaryKey"`
+
+	LastRun          time.Time
+	LastError        time.Time
+	LastErrorMessage string
+}
+
+// Snapshot of storage unit contents from the last import.
+type SteamQueryV2StorageItem struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;"`
+
+	ItemName string
+	Count    int
+	Unit     string
+	Created  time.Time
+}
+
+// Single fetch of all inventories, the items reference it by snapshot id.
+type SteamQueryV2InventorySnapshot struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;"`
+
+	Created time.Time
+}
+
+// Inventory asset of a snapshot, storage unit contents have an empty asset id.
+type SteamQueryV2InventoryItem struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;"`
+	SnapshotID uuid.UUID `gorm:"type:uuid;index"`
+
+	Account  string
+	AssetID  string
+	ItemName string
+	Count    int
+	Created  time.Time
+}
+
+// Item metadata from the inventory tags per market hash name.
+type SteamQueryV2ItemMetadata struct {
+	ItemName string `gorm:"primaryKey"`
+
+	Rarity     string
+	Type       string
+	Collection string
+	Exterior   string
+	Tradable   bool
+	Marketable bool
+	Updated    time.Time
+}
+
+// Single Steam status check, the checks of one run share the run id.
+type SteamQueryV2SteamStatus struct {
+	ID    uuid.UUID `gorm:"type:uuid;primary_key;"`
+	RunID uuid.UUID `gorm:"type:uuid;index"`
+
+	Attempt        int
+	SessionsLogon  string
+	SteamCommunity string
+	IEconItems     string
+	Leaderboards   string
+	// JSON encoded load per datacenter.
+	Datacenters string
+	Up          bool
+	Error       string
+	Created     time.Time
+}
+
 func (s *SteamQueryV2Values) BeforeCreate(tx *gorm.DB) (err error) {
 	s.ID = uuid.New()
 	return
 }
 
+func (s *SteamQueryV2StorageItem) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
+
 func SortByDate(data []*SteamQueryV2Values) {
 	sort.Slice(data, func(i, j int) bool {
 		return data[i].Created.Before(data[j].Created)
 	})
 }
+
+func (s *SteamQueryV2InventorySnapshot) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+func (s *SteamQueryV2InventoryItem) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
+
+func (s *SteamQueryV2SteamStatus) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
@@ -10,8 +10,11 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 
+	"github.com/google/uuid"
+
 	"github.com/devusSs/steamquery-v2/config"
 	"github.com/devusSs/steamquery-v2/logging"
 	"github.com/devusSs/steamquery-v2/statistics/database"
@@ -97,7 +100,15 @@ func (p *psql) VerifyVersion() error {
 }
 
 func (p *psql) Migrate() error {
-	return p.db.AutoMigrate(&database.SteamQueryV2Values{})
+	return p.db.AutoMigrate(
+		&database.SteamQueryV2Values{},
+		&database.SteamQueryV2RunState{},
+		&database.SteamQueryV2StorageItem{},
+		&database.SteamQueryV2InventorySnapshot{},
+		&database.SteamQueryV2InventoryItem{},
+		&database.SteamQueryV2ItemMetadata{},
+		&database.SteamQueryV2SteamStatus{},
+	)
 }
 
 func (p *psql) DeleteOldValues() error {
@@ -105,6 +116,20 @@ func (p *psql) DeleteOldValues() error {
 	logging.LogDebug(fmt.Sprintf("Deleting database values older than %v", oldValuesTreshhold))
 	tx := p.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2Values{})
 	logging.LogDebug(fmt.Sprintf("OLD VALUES AFFECTED: %d", tx.RowsAffected))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = p.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2InventoryItem{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = p.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2InventorySnapshot{})
+	logging.LogDebug(fmt.Sprintf("OLD SNAPSHOTS AFFECTED: %d", tx.RowsAffected))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = p.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2SteamStatus{})
+	logging.LogDebug(fmt.Sprintf("OLD STEAM STATUS AFFECTED: %d", tx.RowsAffected))
 	return tx.Error
 }
 
@@ -168,6 +193,102 @@ func (p *psql) GetValuesByItemNameAndDate(
 	return returns, tx.Error
 }
 
+func (p *psql) GetRunState(portfolio string) (*database.SteamQueryV2RunState, error) {
+	state := &database.SteamQueryV2RunState{Portfolio: portfolio}
+	tx := p.db.Where("portfolio = ?", portfolio).Limit(1).Find(state)
+	return state, tx.Error
+}
+
+func (p *psql) SaveRunState(state *database.SteamQueryV2RunState) error {
+	tx := p.db.Save(state)
+	return tx.Error
+}
+
+func (p *psql) GetStorageItems() ([]*database.SteamQueryV2StorageItem, error) {
+	var returns []*database.SteamQueryV2StorageItem
+	tx := p.db.Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *psql) ReplaceStorageItems(items []*database.SteamQueryV2StorageItem) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&database.SteamQueryV2StorageItem{}).Error; err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		return tx.Create(items).Error
+	})
+}
+
+func (p *psql) AddInventorySnapshot(
+	snapshot *database.SteamQueryV2InventorySnapshot,
+	items []*database.SteamQueryV2InventoryItem,
+) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			item.SnapshotID = snapshot.ID
+			item.Created = snapshot.Created
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(items, 500).Error
+	})
+}
+
+func (p *psql) GetLatestInventorySnapshots(limit int) ([]*database.SteamQueryV2InventorySnapshot, error) {
+	var returns []*database.SteamQueryV2InventorySnapshot
+	tx := p.db.Order("created desc").Limit(limit).Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *psql) GetInventoryItems(snapshotID uuid.UUID) ([]*database.SteamQueryV2InventoryItem, error) {
+	var returns []*database.SteamQueryV2InventoryItem
+	tx := p.db.Where("snapshot_id = ?", snapshotID).Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *psql) SaveItemMetadata(items []*database.SteamQueryV2ItemMetadata) error {
+	if len(items) == 0 {
+		return nil
+	}
+	tx := p.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(items)
+	return tx.Error
+}
+
+func (p *psql) GetItemMetadata() ([]*database.SteamQueryV2ItemMetadata, error) {
+	var returns []*database.SteamQueryV2ItemMetadata
+	tx := p.db.Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *psql) AddSteamStatus(status *database.SteamQueryV2SteamStatus) error {
+	tx := p.db.Create(status)
+	return tx.Error
+}
+
+func (p *psql) GetSteamStatusByDate(
+	startTime time.Time,
+	endTime time.Time,
+) ([]*database.SteamQueryV2SteamStatus, error) {
+	var returns []*database.SteamQueryV2SteamStatus
+	tx := p.db.
+		Where("created >= ? AND created <= ?", startTime.In(time.UTC), endTime.In(time.UTC)).
+		Order("created asc").
+		Find(&returns)
+	return returns, tx.Error
+}
+
 func createPostgresLogFile(dir string) (*os.File, error) {
 	f, err := os.Create(fmt.Sprintf("%s/postgres.log", dir))
 	if err != nil {
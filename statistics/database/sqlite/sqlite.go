@@ -11,8 +11,10 @@ import (
 	"github.com/devusSs/steamquery-v2/config"
 	"github.com/devusSs/steamquery-v2/logging"
 	"github.com/devusSs/steamquery-v2/statistics/database"
+	"github.com/google/uuid"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -88,7 +90,15 @@ func (p *sql) VerifyVersion() error {
 }
 
 func (s *sql) Migrate() error {
-	return s.db.AutoMigrate(&database.SteamQueryV2Values{})
+	return s.db.AutoMigrate(
+		&database.SteamQueryV2Values{},
+		&database.SteamQueryV2RunState{},
+		&database.SteamQueryV2StorageItem{},
+		&database.SteamQueryV2InventorySnapshot{},
+		&database.SteamQueryV2InventoryItem{},
+		&database.SteamQueryV2ItemMetadata{},
+		&database.SteamQueryV2SteamStatus{},
+	)
 }
 
 func (s *sql) DeleteOldValues() error {
@@ -96,6 +106,20 @@ func (s *sql) DeleteOldValues() error {
 	logging.LogDebug(fmt.Sprintf("Deleting database values older than %v", oldValuesTreshhold))
 	tx := s.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2Values{})
 	logging.LogDebug(fmt.Sprintf("OLD VALUES AFFECTED: %d", tx.RowsAffected))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = s.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2InventoryItem{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = s.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2InventorySnapshot{})
+	logging.LogDebug(fmt.Sprintf("OLD SNAPSHOTS AFFECTED: %d", tx.RowsAffected))
+	if tx.Error != nil {
+		return tx.Error
+	}
+	tx = s.db.Where("created < ?", oldValuesTreshhold).Delete(&database.SteamQueryV2SteamStatus{})
+	logging.LogDebug(fmt.Sprintf("OLD STEAM STATUS AFFECTED: %d", tx.RowsAffected))
 	return tx.Error
 }
 
@@ -159,6 +183,102 @@ func (p *sql) GetValuesByItemNameAndDate(
 	return returns, tx.Error
 }
 
+func (p *sql) GetRunState(portfolio string) (*database.SteamQueryV2RunState, error) {
+	state := &database.SteamQueryV2RunState{Portfolio: portfolio}
+	tx := p.db.Where("portfolio = ?", portfolio).Limit(1).Find(state)
+	return state, tx.Error
+}
+
+func (p *sql) SaveRunState(state *database.SteamQueryV2RunState) error {
+	tx := p.db.Save(state)
+	return tx.Error
+}
+
+func (p *sql) GetStorageItems() ([]*database.SteamQueryV2StorageItem, error) {
+	var returns []*database.SteamQueryV2StorageItem
+	tx := p.db.Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *sql) ReplaceStorageItems(items []*database.SteamQueryV2StorageItem) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&database.SteamQueryV2StorageItem{}).Error; err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		return tx.Create(items).Error
+	})
+}
+
+func (p *sql) AddInventorySnapshot(
+	snapshot *database.SteamQueryV2InventorySnapshot,
+	items []*database.SteamQueryV2InventoryItem,
+) error {
+	return p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			item.SnapshotID = snapshot.ID
+			item.Created = snapshot.Created
+		}
+
+		if len(items) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(items, 500).Error
+	})
+}
+
+func (p *sql) GetLatestInventorySnapshots(limit int) ([]*database.SteamQueryV2InventorySnapshot, error) {
+	var returns []*database.SteamQueryV2InventorySnapshot
+	tx := p.db.Order("created desc").Limit(limit).Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *sql) GetInventoryItems(snapshotID uuid.UUID) ([]*database.SteamQueryV2InventoryItem, error) {
+	var returns []*database.SteamQueryV2InventoryItem
+	tx := p.db.Where("snapshot_id = ?", snapshotID).Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *sql) SaveItemMetadata(items []*database.SteamQueryV2ItemMetadata) error {
+	if len(items) == 0 {
+		return nil
+	}
+	tx := p.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(items)
+	return tx.Error
+}
+
+func (p *sql) GetItemMetadata() ([]*database.SteamQueryV2ItemMetadata, error) {
+	var returns []*database.SteamQueryV2ItemMetadata
+	tx := p.db.Find(&returns)
+	return returns, tx.Error
+}
+
+func (p *sql) AddSteamStatus(status *database.SteamQueryV2SteamStatus) error {
+	tx := p.db.Create(status)
+	return tx.Error
+}
+
+func (p *sql) GetSteamStatusByDate(
+	startTime time.Time,
+	endTime time.Time,
+) ([]*database.SteamQueryV2SteamStatus, error) {
+	var returns []*database.SteamQueryV2SteamStatus
+	tx := p.db.
+		Where("created >= ? AND created <= ?", startTime.In(time.UTC), endTime.In(time.UTC)).
+		Order("created asc").
+		Find(&returns)
+	return returns, tx.Error
+}
+
 func createLogFile(dir string) (*os.File, error) {
 	f, err := os.Create(fmt.Sprintf("%s/sqlite.log", dir))
 	if err != nil {
@@ -0,0 +1,156 @@
+package statistics
+
+import (
+	"time"
+
+	"github.com/devusSs/steamquery-v2/statistics/database"
+)
+
+// Account name used for storage unit contents in inventory snapshots.
+//
+// Reserved, config.CheckConfig rejects Steam accounts using it as label.
+const StorageAccount = "storage_units"
+
+// Changes per item name between two inventory snapshots.
+type InventoryReport struct {
+	From             time.Time
+	To               time.Time
+	Acquired         map[string]int
+	Sold             map[string]int
+	MovedToStorage   map[string]int
+	MovedFromStorage map[string]int
+}
+
+// Returns true if nothing changed between the snapshots.
+func (r *InventoryReport) Empty() bool {
+	return len(r.Acquired) == 0 &&
+		len(r.Sold) == 0 &&
+		len(r.MovedToStorage) == 0 &&
+		len(r.MovedFromStorage) == 0
+}
+
+func AddInventorySnapshot(created time.Time, items []*database.SteamQueryV2InventoryItem) error {
+	return service.AddInventorySnapshot(&database.SteamQueryV2InventorySnapshot{Created: created}, items)
+}
+
+// Compares the two latest inventory snapshots, returns nil if there are less than two.
+func CompareLatestInventorySnapshots() (*InventoryReport, error) {
+	snapshots, err := service.GetLatestInventorySnapshots(2)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) < 2 {
+		return nil, nil
+	}
+
+	// Newest first.
+	return CompareInventorySnapshots(snapshots[1], snapshots[0])
+}
+
+// Compares two inventory snapshots by asset ids and storage unit counts.
+//
+// Assets which disappeared while the storage count rose are considered moved to storage,
+// the rest is considered sold. New assets work the same way for items taken out of storage.
+func CompareInventorySnapshots(
+	from *database.SteamQueryV2InventorySnapshot,
+	to *database.SteamQueryV2InventorySnapshot,
+) (*InventoryReport, error) {
+	fromItems, err := service.GetInventoryItems(from.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	toItems, err := service.GetInventoryItems(to.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAssets, fromStorage := splitSnapshotItems(fromItems)
+	toAssets, toStorage := splitSnapshotItems(toItems)
+
+	added := make(map[string]int)
+	removed := make(map[string]int)
+
+	for assetID, item := range toAssets {
+		if _, ok := fromAssets[assetID]; !ok {
+			added[item.ItemName] += item.Count
+		}
+	}
+
+	for assetID, item := range fromAssets {
+		if _, ok := toAssets[assetID]; !ok {
+			removed[item.ItemName] += item.Count
+		}
+	}
+
+	report := &InventoryReport{
+		From:             from.Created,
+		To:               to.Created,
+		Acquired:         make(map[string]int),
+		Sold:             make(map[string]int),
+		MovedToStorage:   make(map[string]int),
+		MovedFromStorage: make(map[string]int),
+	}
+
+	for item, count := range removed {
+		storageDelta := toStorage[item] - fromStorage[item]
+		moved := minInt(count, maxInt(storageDelta, 0))
+
+		if moved > 0 {
+			report.MovedToStorage[item] = moved
+		}
+
+		if count-moved > 0 {
+			report.Sold[item] = count - moved
+		}
+	}
+
+	for item, count := range added {
+		storageDelta := fromStorage[item] - toStorage[item]
+		moved := minInt(count, maxInt(storageDelta, 0))
+
+		if moved > 0 {
+			report.MovedFromStorage[item] = moved
+		}
+
+		if count-moved > 0 {
+			report.Acquired[item] = count - moved
+		}
+	}
+
+	return report, nil
+}
+
+// Splits snapshot items into inventory assets by asset id and storage counts by item name.
+func splitSnapshotItems(
+	items []*database.SteamQueryV2InventoryItem,
+) (map[string]*database.SteamQueryV2InventoryItem, map[string]int) {
+	assets := make(map[string]*database.SteamQueryV2InventoryItem)
+	storage := make(map[string]int)
+
+	for _, item := range items {
+		if item.Account == StorageAccount {
+			storage[item.ItemName] += item.Count
+			continue
+		}
+
+		assets[item.Account+"_"+item.AssetID] = item
+	}
+
+	return assets, storage
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
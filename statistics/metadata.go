@@ -0,0 +1,103 @@
+package statistics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/devusSs/steamquery-v2/statistics/database"
+)
+
+// Metadata fields items can be grouped by in the analysis.
+const (
+	GroupRarity     = "rarity"
+	GroupType       = "type"
+	GroupCollection = "collection"
+	GroupExterior   = "exterior"
+)
+
+// Group used for items without stored metadata.
+const unknownGroup = "unknown"
+
+// Returns true if the grouping is supported, an empty grouping means no grouping.
+func IsValidGrouping(grouping string) bool {
+	switch grouping {
+	case "", GroupRarity, GroupType, GroupCollection, GroupExterior:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the metadata value for the given field (rarity, type, collection or exterior).
+func MetadataValue(metadata *database.SteamQueryV2ItemMetadata, field string) string {
+	switch field {
+	case GroupRarity:
+		return metadata.Rarity
+	case GroupType:
+		return metadata.Type
+	case GroupCollection:
+		return metadata.Collection
+	case GroupExterior:
+		return metadata.Exterior
+	default:
+		return ""
+	}
+}
+
+// Helper function to merge the values of items by their metadata group.
+//
+// The unit prices of a group are summed up per run (amounts are not stored),
+// rows are matched per minute since older runs stamped every item on its own.
+func groupResults(
+	results []*database.SteamQueryV2Values,
+	grouping string,
+) ([]*database.SteamQueryV2Values, error) {
+	metadata, err := service.GetItemMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadata) == 0 {
+		return nil, errors.New("no item metadata on database yet, run with beta features first")
+	}
+
+	groupMap := make(map[string]string)
+	for _, item := range metadata {
+		group := MetadataValue(item, grouping)
+		if group == "" {
+			group = unknownGroup
+		}
+		groupMap[item.ItemName] = group
+	}
+
+	type groupKey struct {
+		group   string
+		created time.Time
+	}
+
+	grouped := make(map[groupKey]*database.SteamQueryV2Values)
+	var groupedResults []*database.SteamQueryV2Values
+
+	for _, result := range results {
+		group, ok := groupMap[result.ItemName]
+		if !ok {
+			group = unknownGroup
+		}
+
+		key := groupKey{group: group, created: result.Created.Truncate(time.Minute)}
+
+		value, ok := grouped[key]
+		if !ok {
+			value = &database.SteamQueryV2Values{ItemName: group, Created: key.created}
+			grouped[key] = value
+			groupedResults = append(groupedResults, value)
+		}
+
+		value.Price += result.Price
+		value.Volume += result.Volume
+	}
+
+	database.SortByDate(groupedResults)
+
+	return groupedResults, nil
+}
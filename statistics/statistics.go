@@ -112,6 +112,34 @@ func AddStatistics(model *database.SteamQueryV2Values) error {
 	return service.AddValues(model)
 }
 
+func GetRunState(portfolio string) (*database.SteamQueryV2RunState, error) {
+	return service.GetRunState(portfolio)
+}
+
+func SaveRunState(state *database.SteamQueryV2RunState) error {
+	return service.SaveRunState(state)
+}
+
+func GetStorageItems() ([]*database.SteamQueryV2StorageItem, error) {
+	return service.GetStorageItems()
+}
+
+func ReplaceStorageItems(items []*database.SteamQueryV2StorageItem) error {
+	return service.ReplaceStorageItems(items)
+}
+
+func SaveItemMetadata(items []*database.SteamQueryV2ItemMetadata) error {
+	return service.SaveItemMetadata(items)
+}
+
+func GetItemMetadata() ([]*database.SteamQueryV2ItemMetadata, error) {
+	return service.GetItemMetadata()
+}
+
+func AddSteamStatus(status *database.SteamQueryV2SteamStatus) error {
+	return service.AddSteamStatus(status)
+}
+
 func StartStatsAnalysis(cfg *config.Postgres, logsDir, dbType string) {
 	switch dbType {
 	case DBPostgres:
@@ -195,6 +223,33 @@ func StartStatsAnalysis(cfg *config.Postgres, logsDir, dbType string) {
 
 	dateRange := text
 
+	fmt.Println("")
+	fmt.Println(
+		"Enter a grouping for the chart (rarity, type, collection or exterior), leave blank for none",
+	)
+	fmt.Println("Grouping uses the item metadata from the inventory (beta features)")
+	fmt.Print("-> ")
+	text, err = reader.ReadString('\n')
+	if err != nil {
+		logging.LogError(err.Error())
+		if err := exitStats(); err != nil {
+			logging.LogFatal(err.Error())
+		}
+		return
+	}
+	text = strings.ToLower(strings.ReplaceAll(text, "\n", ""))
+
+	grouping := text
+
+	if !IsValidGrouping(grouping) {
+		fmt.Println("")
+		fmt.Printf("invalid grouping: %s, exiting\n", grouping)
+		if err := exitStats(); err != nil {
+			logging.LogFatal(err.Error())
+		}
+		return
+	}
+
 	var itemNamesFinal []string
 
 	itemNamesFinal = itemNames
@@ -211,9 +266,13 @@ func StartStatsAnalysis(cfg *config.Postgres, logsDir, dbType string) {
 	fmt.Println("Following inputs will be analysed:")
 	fmt.Printf("Item names: %s\n", strings.Join(itemNamesFinal, ", "))
 	fmt.Printf("Date range: %s\n", dateRange)
+	if grouping != "" {
+		fmt.Printf("Grouping: %s\n", grouping)
+	}
 
 	logging.LogDebug(fmt.Sprintf("item names: %v", itemNamesFinal))
 	logging.LogDebug(fmt.Sprintf("date range: %v", dateRange))
+	logging.LogDebug(fmt.Sprintf("grouping: %v", grouping))
 
 	fmt.Println("")
 	fmt.Println("Are these inputs correct (y/n)?")
@@ -231,7 +290,7 @@ func StartStatsAnalysis(cfg *config.Postgres, logsDir, dbType string) {
 	switch text {
 	case "y":
 		fmt.Println("")
-		if err := performAnalysis(itemNamesFinal, dateRange, logsDir); err != nil {
+		if err := performAnalysis(itemNamesFinal, dateRange, grouping, logsDir); err != nil {
 			logging.LogError(err.Error())
 			if err := exitStats(); err != nil {
 				logging.LogFatal(err.Error())
@@ -359,7 +418,7 @@ func checkDatabase() error {
 	return nil
 }
 
-func performAnalysis(itemNames []string, dateRange, logsDir string) error {
+func performAnalysis(itemNames []string, dateRange, grouping, logsDir string) error {
 	var results []*database.SteamQueryV2Values
 	var err error
 	writeDir := fmt.Sprintf("%s/%s", logsDir, analysisDir)
@@ -461,9 +520,23 @@ func performAnalysis(itemNames []string, dateRange, logsDir string) error {
 		)
 	}
 
+	if grouping != "" {
+		logging.LogInfo(fmt.Sprintf("Grouping items by %s, please wait", grouping))
+
+		results, err = groupResults(results, grouping)
+		if err != nil {
+			return err
+		}
+	}
+
 	logging.LogInfo("Generating and writing chart, please wait")
 
-	chart, err := generateChart(results)
+	yAxisName := "Price in €"
+	if grouping != "" {
+		yAxisName = "Sum of unit prices in €"
+	}
+
+	chart, err := generateChart(results, yAxisName)
 	if err != nil {
 		return err
 	}
@@ -485,7 +558,7 @@ func performAnalysis(itemNames []string, dateRange, logsDir string) error {
 	return nil
 }
 
-func generateChart(results []*database.SteamQueryV2Values) (*charts.Line, error) {
+func generateChart(results []*database.SteamQueryV2Values, yAxisName string) (*charts.Line, error) {
 	var dateRange []string
 	prices := make(map[string][]float64)
 
@@ -510,7 +583,7 @@ func generateChart(results []*database.SteamQueryV2Values) (*charts.Line, error)
 			Height:    "800px",
 		}),
 		charts.WithXAxisOpts(opts.XAxis{Name: "Datetime"}),
-		charts.WithYAxisOpts(opts.YAxis{Name: "Price in €"}),
+		charts.WithYAxisOpts(opts.YAxis{Name: yAxisName}),
 		charts.WithLegendOpts(opts.Legend{
 			Show:    true,
 			Type:    "scroll",
@@ -0,0 +1,288 @@
+package statistics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/types"
+	"github.com/google/uuid"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/statistics/database"
+)
+
+// Range of the Steam status report, older checks get deleted anyway.
+const steamStatusReportRange = 30 * 24 * time.Hour
+
+// Steam services stored per status check.
+var steamServices = []string{"SessionsLogon", "SteamCommunity", "IEconItems", "Leaderboards"}
+
+// Availability of Steam and delayed runs based on the stored status checks.
+type SteamStatusReport struct {
+	From         time.Time
+	To           time.Time
+	Checks       int
+	UpChecks     int
+	FailedChecks int
+	// Count per status per service, failed checks are not included.
+	Services    map[string]map[string]int
+	Runs        int
+	DelayedRuns int
+	GaveUpRuns  int
+	TotalDelay  time.Duration
+}
+
+// Prints the Steam status report for the last 30 days and writes an availability chart.
+func StartSteamStatusReport(cfg *config.Postgres, logsDir, dbType string) {
+	if err := SetupStatistics(cfg, logsDir, dbType); err != nil {
+		logging.LogError(err.Error())
+		return
+	}
+
+	if err := performSteamStatusReport(logsDir); err != nil {
+		logging.LogError(err.Error())
+	}
+
+	if err := exitStats(); err != nil {
+		logging.LogFatal(err.Error())
+	}
+}
+
+func performSteamStatusReport(logsDir string) error {
+	if err := logging.CreateLogsDirectory(fmt.Sprintf("%s/%s", logsDir, analysisDir)); err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-steamStatusReportRange)
+
+	statuses, err := service.GetSteamStatusByDate(startTime, endTime)
+	if err != nil {
+		return err
+	}
+
+	if len(statuses) == 0 {
+		return errors.New("no steam status checks on database yet")
+	}
+
+	report := generateSteamStatusReport(statuses)
+
+	printSteamStatusReport(report)
+
+	logging.LogInfo("Generating and writing Steam status chart, please wait")
+
+	chart := generateSteamStatusChart(statuses)
+
+	fileName := fmt.Sprintf("%s/%s/%s_steam_status.html", logsDir, analysisDir, dateFormat)
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := chart.Render(f); err != nil {
+		return err
+	}
+
+	logging.LogSuccess(fmt.Sprintf("Wrote Steam status chart to file: %s", fileName))
+
+	return nil
+}
+
+// Generates the availability report from status checks ordered by date.
+//
+// A run counts as delayed if its first check found Steam down.
+func generateSteamStatusReport(statuses []*database.SteamQueryV2SteamStatus) *SteamStatusReport {
+	report := &SteamStatusReport{
+		From:     statuses[0].Created,
+		To:       statuses[len(statuses)-1].Created,
+		Services: make(map[string]map[string]int),
+	}
+
+	runs := make(map[uuid.UUID][]*database.SteamQueryV2SteamStatus)
+
+	for _, status := range statuses {
+		report.Checks++
+
+		if status.Up {
+			report.UpChecks++
+		}
+
+		runs[status.RunID] = append(runs[status.RunID], status)
+
+		if status.Error != "" {
+			report.FailedChecks++
+			continue
+		}
+
+		for i, value := range steamServiceValues(status) {
+			name := steamServices[i]
+
+			if report.Services[name] == nil {
+				report.Services[name] = make(map[string]int)
+			}
+
+			if value == "" {
+				value = "unknown"
+			}
+
+			report.Services[name][value]++
+		}
+	}
+
+	for _, checks := range runs {
+		sort.Slice(checks, func(i, j int) bool {
+			return checks[i].Attempt < checks[j].Attempt
+		})
+
+		report.Runs++
+
+		if checks[0].Up {
+			continue
+		}
+
+		report.DelayedRuns++
+
+		last := checks[len(checks)-1]
+
+		if !last.Up {
+			report.GaveUpRuns++
+			continue
+		}
+
+		report.TotalDelay += last.Created.Sub(checks[0].Created)
+	}
+
+	return report
+}
+
+// Helper function which returns the service states in the order of steamServices.
+func steamServiceValues(status *database.SteamQueryV2SteamStatus) []string {
+	return []string{
+		status.SessionsLogon,
+		status.SteamCommunity,
+		status.IEconItems,
+		status.Leaderboards,
+	}
+}
+
+func printSteamStatusReport(report *SteamStatusReport) {
+	fmt.Println("")
+	fmt.Printf(
+		"Steam status report (%s - %s)\n",
+		report.From.Local().Format("2006-01-02 15:04"),
+		report.To.Local().Format("2006-01-02 15:04"),
+	)
+	fmt.Println("")
+	fmt.Printf(
+		"Checks: %d (up: %d, %.2f%%, failed to fetch: %d)\n",
+		report.Checks,
+		report.UpChecks,
+		float64(report.UpChecks)/float64(report.Checks)*100,
+		report.FailedChecks,
+	)
+
+	for _, name := range steamServices {
+		counts := report.Services[name]
+		if len(counts) == 0 {
+			continue
+		}
+
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+
+		var states []string
+		for state := range counts {
+			states = append(states, state)
+		}
+		sort.Strings(states)
+
+		fmt.Printf("%s:", name)
+		for _, state := range states {
+			fmt.Printf(" %s %.2f%%", state, float64(counts[state])/float64(total)*100)
+		}
+		fmt.Println("")
+	}
+
+	fmt.Println("")
+	fmt.Printf(
+		"Runs: %d (delayed by outages: %d, gave up: %d)\n",
+		report.Runs,
+		report.DelayedRuns,
+		report.GaveUpRuns,
+	)
+
+	if report.DelayedRuns > report.GaveUpRuns {
+		fmt.Printf(
+			"Average delay: %v\n",
+			(report.TotalDelay / time.Duration(report.DelayedRuns-report.GaveUpRuns)).Round(time.Second),
+		)
+	}
+
+	fmt.Println("")
+}
+
+// Helper function which charts every service as 0 (normal), 1 (delayed) or 2 (down).
+func generateSteamStatusChart(statuses []*database.SteamQueryV2SteamStatus) *charts.Line {
+	var dateRange []string
+	values := make(map[string][]opts.LineData)
+
+	for _, status := range statuses {
+		dateRange = append(dateRange, status.Created.Local().Format("2006-01-02 15:04:05"))
+
+		for i, value := range steamServiceValues(status) {
+			// Failed checks have no service states and count as down.
+			level := 2
+
+			switch value {
+			case "normal":
+				level = 0
+			case "delayed":
+				level = 1
+			}
+
+			values[steamServices[i]] = append(values[steamServices[i]], opts.LineData{Value: level})
+		}
+	}
+
+	line := charts.NewLine()
+
+	line.SetGlobalOptions(
+		charts.WithInitializationOpts(opts.Initialization{
+			PageTitle: "Steamquery-v2 Steam Status",
+			Theme:     types.ThemeInfographic,
+			Width:     "1000px",
+			Height:    "800px",
+		}),
+		charts.WithXAxisOpts(opts.XAxis{Name: "Datetime"}),
+		charts.WithYAxisOpts(opts.YAxis{Name: "0 normal, 1 delayed, 2 down", Max: 2}),
+		charts.WithLegendOpts(opts.Legend{
+			Show:    true,
+			Type:    "scroll",
+			Padding: [4]int{5, 5, 20, 5},
+		}),
+		charts.WithTooltipOpts(
+			opts.Tooltip{Show: true},
+		),
+	)
+
+	line.SetXAxis(dateRange).
+		SetSeriesOptions(
+			charts.WithLineChartOpts(opts.LineChart{Step: true, ShowSymbol: true}),
+		)
+
+	for _, name := range steamServices {
+		line.AddSeries(name, values[name])
+	}
+
+	return line
+}
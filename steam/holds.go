@@ -0,0 +1,75 @@
+package steam
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/devusSs/steamquery-v2/types"
+)
+
+// Trade and market hold info of all assets of an item.
+type ItemHold struct {
+	MarketHashName string
+	Total          int
+	Locked         int
+	// Earliest hold expiration of the locked assets, zero if unknown.
+	HoldUntil time.Time
+}
+
+// Collects the trade and market holds of all inventory items by market hash name.
+//
+// Only items with at least one locked asset are returned.
+func GetItemHolds(accountMap map[string][]InventoryItem) map[string]ItemHold {
+	holdMap := make(map[string]ItemHold)
+
+	for _, items := range accountMap {
+		for _, item := range items {
+			hold := holdMap[item.MarketHashName]
+			hold.MarketHashName = item.MarketHashName
+			hold.Total += item.Amount
+
+			if item.Locked() {
+				hold.Locked += item.Amount
+
+				if !item.HoldUntil.IsZero() &&
+					(hold.HoldUntil.IsZero() || item.HoldUntil.Before(hold.HoldUntil)) {
+					hold.HoldUntil = item.HoldUntil
+				}
+			}
+
+			holdMap[item.MarketHashName] = hold
+		}
+	}
+
+	for name, hold := range holdMap {
+		if hold.Locked == 0 {
+			delete(holdMap, name)
+		}
+	}
+
+	return holdMap
+}
+
+// Helper function which parses the hold expiration of an inventory description.
+//
+// Steam only sends a cache expiration for items on a (temporary) trade or market hold.
+func getHoldExpiration(desc *types.SteamInventoryDescription) (time.Time, error) {
+	if desc.CacheExpiration == "" {
+		return time.Time{}, nil
+	}
+
+	holdUntil, err := time.Parse(time.RFC3339, desc.CacheExpiration)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"malformed cache expiration for %s: %s",
+			desc.MarketHashName,
+			desc.CacheExpiration,
+		)
+	}
+
+	if holdUntil.Before(time.Now()) {
+		return time.Time{}, nil
+	}
+
+	return holdUntil, nil
+}
@@ -0,0 +1,67 @@
+package steam
+
+import "github.com/devusSs/steamquery-v2/types"
+
+// Tag categories used by the Steam inventory for CSGO items.
+const (
+	tagCategoryRarity     = "Rarity"
+	tagCategoryType       = "Type"
+	tagCategoryCollection = "ItemSet"
+	tagCategoryExterior   = "Exterior"
+)
+
+// Item metadata extracted from the inventory description tags.
+type ItemMetadata struct {
+	MarketHashName string
+	Rarity         string
+	Type           string
+	Collection     string
+	Exterior       string
+	Tradable       bool
+	Marketable     bool
+}
+
+// Collects the metadata of all inventory items by market hash name.
+func GetItemMetadata(accountMap map[string][]InventoryItem) map[string]ItemMetadata {
+	metadataMap := make(map[string]ItemMetadata)
+
+	for _, items := range accountMap {
+		for _, item := range items {
+			if item.Description == nil {
+				continue
+			}
+
+			// Tradable may differ between assets (trade holds), prefer the tradable one.
+			if existing, ok := metadataMap[item.MarketHashName]; ok && existing.Tradable {
+				continue
+			}
+
+			metadataMap[item.MarketHashName] = newItemMetadata(item.Description)
+		}
+	}
+
+	return metadataMap
+}
+
+func newItemMetadata(desc *types.SteamInventoryDescription) ItemMetadata {
+	metadata := ItemMetadata{
+		MarketHashName: desc.MarketHashName,
+		Tradable:       desc.Tradable == 1,
+		Marketable:     desc.Marketable == 1,
+	}
+
+	for _, tag := range desc.Tags {
+		switch tag.Category {
+		case tagCategoryRarity:
+			metadata.Rarity = tag.LocalizedTagName
+		case tagCategoryType:
+			metadata.Type = tag.LocalizedTagName
+		case tagCategoryCollection:
+			metadata.Collection = tag.LocalizedTagName
+		case tagCategoryExterior:
+			metadata.Exterior = tag.LocalizedTagName
+		}
+	}
+
+	return metadata
+}
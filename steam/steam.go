@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,27 +19,93 @@ import (
 type steamStatus int
 
 const (
-	statusAPIURL = "https://api.steampowered.com/ICSGOServers_730/GetGameServersStatus/v1/?key="
+	steamNormal steamStatus = iota
+	steamDelayed
+	steamDown
+)
+
+// Decides which Steam services need to be up for IsSteamCSGOAPIUp.
+type HealthPolicy struct {
+	RequiredServices []string
+	DelayedIsUp      bool
+}
+
+// Services required if the health policy does not specify any.
+var defaultRequiredServices = []string{"SessionsLogon", "SteamCommunity"}
+
+// Steam account whose inventory gets fetched, the label is used in logs and snapshots.
+type Account struct {
+	Label     string
+	SteamID64 uint64
+}
+
+var healthPolicy = HealthPolicy{RequiredServices: defaultRequiredServices, DelayedIsUp: true}
+
+var (
+	statusAPIURL     = "https://api.steampowered.com/ICSGOServers_730/GetGameServersStatus/v1/"
+	priceOverviewURL = "https://steamcommunity.com/market/priceoverview/"
+	inventoryURL     = "https://steamcommunity.com/inventory"
+
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+	// Price requests run one after another, so they time out sooner.
+	priceClient = &http.Client{Timeout: 3 * time.Second}
+)
 
-	steamNormal  steamStatus = iota
-	steamDelayed steamStatus = iota
-	steamDown    steamStatus = iota
+var (
+	// Returned by GetItemMarketPrice when Steam rate limited us.
+	ErrTooManyRequests = errors.New("too many requests to Steam")
+	// Returned by GetItemMarketPrice when the item could not be found on the market.
+	ErrItemNotFound = errors.New("item not found on Steam community market")
 )
 
-// Actual check on the Steam API for status of CSGO servers.
-func IsSteamCSGOAPIUp(apiKey string) (bool, error) {
+// Sets the Steam endpoints and HTTP clients, the price client is used for market price requests.
+//
+// Empty URLs and nil clients keep the defaults.
+func InitSteam(statusURL, priceURL, invURL string, client, pricesClient *http.Client) {
+	if statusURL != "" {
+		statusAPIURL = statusURL
+	}
+
+	if priceURL != "" {
+		priceOverviewURL = priceURL
+	}
+
+	if invURL != "" {
+		inventoryURL = strings.TrimSuffix(invURL, "/")
+	}
+
+	if client != nil {
+		httpClient = client
+	}
+
+	if pricesClient != nil {
+		priceClient = pricesClient
+	}
+}
+
+// Sets the health policy used for IsSteamCSGOAPIUp, no required services keep the defaults.
+func SetHealthPolicy(policy HealthPolicy) {
+	if len(policy.RequiredServices) == 0 {
+		policy.RequiredServices = defaultRequiredServices
+	}
+
+	healthPolicy = policy
+}
+
+// Fetches the status of the CSGO servers and Steam services from the Steam API.
+func GetSteamStatus(apiKey string) (*types.SteamAPIResponse, error) {
 	startTime := time.Now()
 
 	logging.LogInfo("Fetching Steam API status, please wait")
 
-	res, err := http.Get(fmt.Sprintf("%s%s", statusAPIURL, apiKey))
+	res, err := httpClient.Get(statusAPIURL + "?" + url.Values{"key": {apiKey}}.Encode())
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return false, fmt.Errorf(
+		return nil, fmt.Errorf(
 			"unwanted Steam status response: %s (code: %d)",
 			res.Status,
 			res.StatusCode,
@@ -46,7 +114,7 @@ func IsSteamCSGOAPIUp(apiKey string) (bool, error) {
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	system.BytesUsed += len(body)
@@ -54,66 +122,178 @@ func IsSteamCSGOAPIUp(apiKey string) (bool, error) {
 	var resp types.SteamAPIResponse
 
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return false, err
+		return nil, err
 	}
 
-	var steamStatusSessions steamStatus
-	var steamStatusCommunity steamStatus
+	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
 
-	switch resp.Result.Services.SessionsLogon {
-	case "normal":
-		steamStatusSessions = steamNormal
-	case "delayed":
-		steamStatusSessions = steamDelayed
-		logging.LogWarning("Steam sessions logon delayed, expect problems")
-	default:
-		steamStatusSessions = steamDown
+	return &resp, nil
+}
+
+// Actual check of the Steam API status for CSGO servers.
+//
+// Steam counts as up if all services required by the health policy are normal,
+// or delayed if the policy allows it.
+func IsSteamCSGOAPIUp(resp *types.SteamAPIResponse) bool {
+	steamUp := true
+
+	for _, service := range healthPolicy.RequiredServices {
+		switch getServiceStatus(resp, service) {
+		case steamNormal:
+		case steamDelayed:
+			logging.LogWarning(fmt.Sprintf("Steam %s delayed, expect problems", service))
+
+			if !healthPolicy.DelayedIsUp {
+				steamUp = false
+			}
+		default:
+			logging.LogWarning(fmt.Sprintf("Steam %s down", service))
+			steamUp = false
+		}
+	}
+
+	return steamUp
+}
+
+func getServiceStatus(resp *types.SteamAPIResponse, service string) steamStatus {
+	var value string
+
+	switch service {
+	case "SessionsLogon":
+		value = resp.Result.Services.SessionsLogon
+	case "SteamCommunity":
+		value = resp.Result.Services.SteamCommunity
+	case "IEconItems":
+		value = resp.Result.Services.IEconItems
+	case "Leaderboards":
+		value = resp.Result.Services.Leaderboards
 	}
 
-	switch resp.Result.Services.SteamCommunity {
+	switch value {
 	case "normal":
-		steamStatusCommunity = steamNormal
+		return steamNormal
 	case "delayed":
-		steamStatusCommunity = steamDelayed
-		logging.LogWarning("Steam community delayed, expect problems")
+		return steamDelayed
 	default:
-		steamStatusCommunity = steamDown
+		return steamDown
 	}
-
-	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
-
-	return steamStatusSessions < 3 && steamStatusCommunity < 3, nil
 }
 
-func GetAndCompareSteamInventory(
-	apiKey string, steamID64 uint64,
-	itemListMap map[string]int,
-	itemAmountMap map[int]int,
-) (map[string]int, error) {
-	startTime := time.Now()
+// Fetches the price overview for an item on the Steam community market.
+func GetItemMarketPrice(item string) (*types.SteamItemResponse, error) {
+	u := priceOverviewURL + "?" + url.Values{
+		"appid":            {strconv.FormatUint(730, 10)},
+		"country":          {"EN"},
+		"currency":         {"3"},
+		"market_hash_name": {item},
+	}.Encode()
 
-	steamUp, err := IsSteamCSGOAPIUp(apiKey)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if !steamUp {
-		return nil, errors.New("steam down, retry later")
+	req.Header.Set("User-Agent", system.GetUserAgentHeaderFromOS())
+
+	res, err := priceClient.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer res.Body.Close()
 
-	logging.LogSuccess("Steam is up and running")
+	if res.StatusCode != http.StatusOK {
+		if res.StatusCode == http.StatusTooManyRequests {
+			return nil, fmt.Errorf("%w: %s (code: %d)", ErrTooManyRequests, res.Status, res.StatusCode)
+		}
 
-	logging.LogInfo("Fetching Steam CSGO inventory, please wait")
+		if res.StatusCode == http.StatusInternalServerError {
+			return nil, fmt.Errorf("%w: %s", ErrItemNotFound, item)
+		}
 
-	logging.LogWarning("NOTE: this will not work for storage units")
+		return nil, fmt.Errorf(
+			"unwanted Steam response: %s (code: %d)",
+			res.Status,
+			res.StatusCode,
+		)
+	}
 
-	// This function already only fetches marketable items, no need to remove anything.
-	inventoryMap, err := getSteamInventory(steamID64)
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	logging.LogSuccess("Successfully fetched Steam CSGO inventory")
+	system.BytesUsed += len(body)
+
+	var itemMarketResponse types.SteamItemResponse
+
+	if err := json.Unmarshal(body, &itemMarketResponse); err != nil {
+		return nil, err
+	}
+
+	return &itemMarketResponse, nil
+}
+
+// Fetches the inventory items of all accounts, keyed by account label.
+func GetSteamInventories(accounts []Account) (map[string][]InventoryItem, error) {
+	accountMap := make(map[string][]InventoryItem)
+
+	for _, account := range accounts {
+		logging.LogInfo(
+			fmt.Sprintf("Fetching Steam CSGO inventory for account %s, please wait", account.Label),
+		)
+
+		accountItems, err := getSteamInventoryItems(account.SteamID64)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", account.Label, err)
+		}
+
+		accountMap[account.Label] = accountItems
+
+		logging.LogSuccess(
+			fmt.Sprintf("Successfully fetched Steam CSGO inventory for account %s", account.Label),
+		)
+	}
+
+	return accountMap, nil
+}
+
+// Compares the Steam inventory with the sheet and returns the items which need to be written.
+//
+// The returned map contains items missing on the sheet and items with a higher inventory count.
+// Amounts are never decreased since the inventory does not include storage units.
+//
+// Inventories of all accounts and the storage unit counts are merged,
+// the second map holds the inventory items per account label.
+// Callers check the Steam status first, no status request is made here.
+func GetAndCompareSteamInventory(
+	accounts []Account,
+	storageMap map[string]int,
+	itemListMap map[string]int,
+	itemAmountMap map[int]int,
+) (map[string]int, map[string][]InventoryItem, error) {
+	startTime := time.Now()
+
+	if len(storageMap) == 0 {
+		logging.LogWarning("NOTE: no storage unit contents imported, storage units are not included")
+	}
+
+	accountMap, err := GetSteamInventories(accounts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inventoryMap := make(map[string]int)
+
+	// Only counts marketable items, no need to remove anything.
+	for _, accountItems := range accountMap {
+		for item, amount := range CountInventoryItems(accountItems) {
+			inventoryMap[item] += amount
+		}
+	}
+
+	for item, amount := range storageMap {
+		inventoryMap[item] += amount
+	}
 
 	logging.LogInfo("Mapping amounts to name from sheets")
 
@@ -130,7 +310,7 @@ func GetAndCompareSteamInventory(
 
 		amount, ok := itemAmountMap[cell]
 		if !ok {
-			return nil, fmt.Errorf("missing amount for item %s", item)
+			return nil, nil, fmt.Errorf("missing amount for item %s", item)
 		}
 
 		itemNameAmountMap[item] = amount
@@ -148,16 +328,12 @@ func GetAndCompareSteamInventory(
 	for item, amount := range itemNameAmountMap {
 		amountInInv, ok := inventoryMap[item]
 		if !ok {
-			missingAddMap[item] = amount
+			continue
 		}
 
 		if amountInInv > amount {
 			missingAddMap[item] = amountInInv
 		}
-
-		if amountInInv < amount {
-			missingAddMap[item] = amount
-		}
 	}
 
 	for item, amount := range inventoryMap {
@@ -175,24 +351,153 @@ func GetAndCompareSteamInventory(
 
 	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
 
-	return missingAddMap, nil
+	return missingAddMap, accountMap, nil
+}
+
+// Single asset from a Steam inventory joined with its description.
+type InventoryItem struct {
+	AssetID        string
+	ClassID        string
+	InstanceID     string
+	MarketHashName string
+	Amount         int
+	Marketable     bool
+	Tradable       bool
+	// Trade or market hold expiration, zero if the item is not on hold.
+	HoldUntil   time.Time
+	Description *types.SteamInventoryDescription
 }
 
-func getSteamInventory(steamID64 uint64) (map[string]int, error) {
+// Returns true if the item can not be traded or sold yet.
+func (i InventoryItem) Locked() bool {
+	return !i.Tradable || !i.HoldUntil.IsZero()
+}
+
+// Maximum page size the Steam inventory endpoint accepts.
+const inventoryPageSize = 2000
+
+// Counts marketable inventory items by market hash name.
+//
+// Items on a temporary hold count as well since they will be marketable once it expires.
+func CountInventoryItems(items []InventoryItem) map[string]int {
+	itemCountMap := make(map[string]int)
+
+	for _, item := range items {
+		if item.Marketable || !item.HoldUntil.IsZero() {
+			itemCountMap[item.MarketHashName] += item.Amount
+		}
+	}
+
+	return itemCountMap
+}
+
+// Pages through the whole inventory and joins every asset with its description.
+func getSteamInventoryItems(steamID64 uint64) ([]InventoryItem, error) {
 	startTime := time.Now()
 
-	url := fmt.Sprintf("http://steamcommunity.com/inventory/%d/%d/%d", steamID64, 730, 2)
+	var items []InventoryItem
+	startAssetID := ""
+	page := 0
+
+	for {
+		page++
+
+		steamReturn, err := getSteamInventoryPage(steamID64, startAssetID)
+		if err != nil {
+			return nil, err
+		}
+
+		descriptions := make(map[string]*types.SteamInventoryDescription)
+		for i := range steamReturn.Descriptions {
+			description := &steamReturn.Descriptions[i]
+			descriptions[description.Classid+"_"+description.Instanceid] = description
+		}
+
+		for _, asset := range steamReturn.Assets {
+			description, ok := descriptions[asset.Classid+"_"+asset.Instanceid]
+			if !ok {
+				logging.LogWarning(
+					fmt.Sprintf("Missing description for inventory asset %s", asset.Assetid),
+				)
+				continue
+			}
+
+			amount, err := strconv.Atoi(asset.Amount)
+			if err != nil {
+				return nil, err
+			}
+
+			holdUntil, err := getHoldExpiration(description)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, InventoryItem{
+				AssetID:        asset.Assetid,
+				ClassID:        asset.Classid,
+				InstanceID:     asset.Instanceid,
+				MarketHashName: description.MarketHashName,
+				Amount:         amount,
+				Marketable:     description.Marketable == 1,
+				Tradable:       description.Tradable == 1,
+				HoldUntil:      holdUntil,
+				Description:    description,
+			})
+		}
+
+		logging.LogDebug(
+			fmt.Sprintf(
+				"Fetched inventory page %d (%d asset(s), total: %d)",
+				page,
+				len(steamReturn.Assets),
+				steamReturn.TotalInventoryCount,
+			),
+		)
+
+		if steamReturn.MoreItems != 1 || steamReturn.LastAssetid == "" {
+			break
+		}
+
+		startAssetID = steamReturn.LastAssetid
 
-	client := http.Client{}
-	client.Timeout = 2 * time.Second
+		// Avoid running into the inventory endpoint rate limit.
+		time.Sleep(1 * time.Second)
+	}
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
+
+	return items, nil
+}
+
+func getSteamInventoryPage(
+	steamID64 uint64,
+	startAssetID string,
+) (*types.SteamInventoryReturn, error) {
+	query := url.Values{
+		"l":     {"english"},
+		"count": {strconv.Itoa(inventoryPageSize)},
+	}
+
+	if startAssetID != "" {
+		query.Set("start_assetid", startAssetID)
+	}
+
+	u := fmt.Sprintf(
+		"%s/%d/%d/%d?%s",
+		inventoryURL,
+		steamID64,
+		730,
+		2,
+		query.Encode(),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/json")
 
-	res, err := client.Do(req)
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -219,15 +524,9 @@ func getSteamInventory(steamID64 uint64) (map[string]int, error) {
 		return nil, err
 	}
 
-	itemCountMap := make(map[string]int)
-
-	for _, item := range steamReturn.Descriptions {
-		if item.Marketable == 1 {
-			itemCountMap[item.Name]++
-		}
+	if steamReturn.Success != 1 {
+		return nil, fmt.Errorf("unsuccessful Steam inventory response (success: %d)", steamReturn.Success)
 	}
 
-	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
-
-	return itemCountMap, nil
+	return &steamReturn, nil
 }
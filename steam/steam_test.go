@@ -0,0 +1,320 @@
+package steam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/types"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "steamquery-steam-test")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := logging.CreateLogsDirectory(dir); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := logging.InitLoggers("release"); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	_ = logging.CloseLogFiles()
+	_ = os.RemoveAll(dir)
+
+	os.Exit(code)
+}
+
+// Helper function which restores the package endpoints, clients and health policy after a test.
+func resetSteam(t *testing.T) {
+	t.Helper()
+
+	oldStatusURL, oldPriceURL, oldInventoryURL := statusAPIURL, priceOverviewURL, inventoryURL
+	oldClient, oldPriceClient, oldPolicy := httpClient, priceClient, healthPolicy
+
+	t.Cleanup(func() {
+		statusAPIURL, priceOverviewURL, inventoryURL = oldStatusURL, oldPriceURL, oldInventoryURL
+		httpClient, priceClient, healthPolicy = oldClient, oldPriceClient, oldPolicy
+	})
+}
+
+// Helper function which serves a status response with the given service states.
+func newStatusServer(t *testing.T, services map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var resp types.SteamAPIResponse
+		resp.Result.Services.SessionsLogon = services["SessionsLogon"]
+		resp.Result.Services.SteamCommunity = services["SteamCommunity"]
+		resp.Result.Services.IEconItems = services["IEconItems"]
+		resp.Result.Services.Leaderboards = services["Leaderboards"]
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestSteamStatusHealthPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]string
+		policy   HealthPolicy
+		want     bool
+	}{
+		{
+			name:     "default services normal",
+			services: map[string]string{"SessionsLogon": "normal", "SteamCommunity": "normal"},
+			want:     true,
+		},
+		{
+			name:     "default services delayed allowed",
+			services: map[string]string{"SessionsLogon": "delayed", "SteamCommunity": "normal"},
+			policy:   HealthPolicy{DelayedIsUp: true},
+			want:     true,
+		},
+		{
+			name:     "default services delayed not allowed",
+			services: map[string]string{"SessionsLogon": "delayed", "SteamCommunity": "normal"},
+			policy:   HealthPolicy{DelayedIsUp: false},
+			want:     false,
+		},
+		{
+			name:     "default service down",
+			services: map[string]string{"SessionsLogon": "normal", "SteamCommunity": "offline"},
+			policy:   HealthPolicy{DelayedIsUp: true},
+			want:     false,
+		},
+		{
+			name:     "not required service down",
+			services: map[string]string{"SessionsLogon": "normal", "SteamCommunity": "normal"},
+			policy:   HealthPolicy{DelayedIsUp: true},
+			want:     true,
+		},
+		{
+			name:     "custom required service down",
+			services: map[string]string{"SessionsLogon": "normal", "SteamCommunity": "normal", "IEconItems": "offline"},
+			policy:   HealthPolicy{RequiredServices: []string{"IEconItems"}, DelayedIsUp: true},
+			want:     false,
+		},
+		{
+			name:     "custom required service normal",
+			services: map[string]string{"SessionsLogon": "offline", "IEconItems": "normal"},
+			policy:   HealthPolicy{RequiredServices: []string{"IEconItems"}},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSteam(t)
+
+			server := newStatusServer(t, tt.services)
+			InitSteam(server.URL, "", "", server.Client(), nil)
+			SetHealthPolicy(tt.policy)
+
+			status, err := GetSteamStatus("test-key")
+			if err != nil {
+				t.Fatalf("GetSteamStatus() error = %v", err)
+			}
+
+			if got := IsSteamCSGOAPIUp(status); got != tt.want {
+				t.Errorf("IsSteamCSGOAPIUp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSteamStatusUnwantedResponse(t *testing.T) {
+	resetSteam(t)
+
+	server := newStatusServer(t, nil)
+	InitSteam(server.URL, "", "", server.Client(), nil)
+
+	if _, err := GetSteamStatus("wrong-key"); err == nil {
+		t.Fatal("GetSteamStatus() error = nil, want error for status 403")
+	}
+}
+
+func TestGetSteamInventoryItemsPagination(t *testing.T) {
+	resetSteam(t)
+
+	pages := map[string]types.SteamInventoryReturn{
+		"": {
+			Assets: []types.SteamInventoryAsset{
+				{Assetid: "1", Classid: "10", Instanceid: "0", Amount: "1"},
+				{Assetid: "2", Classid: "20", Instanceid: "0", Amount: "1"},
+			},
+			Descriptions: []types.SteamInventoryDescription{
+				{Classid: "10", Instanceid: "0", MarketHashName: "Case A", Marketable: 1},
+				{Classid: "20", Instanceid: "0", MarketHashName: "Case B", Marketable: 1},
+			},
+			MoreItems:   1,
+			LastAssetid: "2",
+			Success:     1,
+		},
+		"2": {
+			Assets: []types.SteamInventoryAsset{
+				{Assetid: "3", Classid: "10", Instanceid: "0", Amount: "1"},
+			},
+			Descriptions: []types.SteamInventoryDescription{
+				{Classid: "10", Instanceid: "0", MarketHashName: "Case A", Marketable: 1},
+			},
+			Success: 1,
+		},
+	}
+
+	var requested []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/76561198000000000/730/2" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		startAssetID := r.URL.Query().Get("start_assetid")
+		requested = append(requested, startAssetID)
+
+		page, ok := pages[startAssetID]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	InitSteam("", "", server.URL+"/", server.Client(), nil)
+
+	items, err := getSteamInventoryItems(76561198000000000)
+	if err != nil {
+		t.Fatalf("getSteamInventoryItems() error = %v", err)
+	}
+
+	if strings.Join(requested, ",") != ",2" {
+		t.Errorf("requested start asset ids = %q, want first page and 2", requested)
+	}
+
+	var assetIDs []string
+	for _, item := range items {
+		assetIDs = append(assetIDs, item.AssetID)
+	}
+
+	if strings.Join(assetIDs, ",") != "1,2,3" {
+		t.Errorf("asset ids = %v, want 1,2,3", assetIDs)
+	}
+
+	counts := CountInventoryItems(items)
+	if counts["Case A"] != 2 || counts["Case B"] != 1 {
+		t.Errorf("CountInventoryItems() = %v, want Case A: 2, Case B: 1", counts)
+	}
+}
+
+func TestGetSteamInventoryItemsUnsuccessful(t *testing.T) {
+	resetSteam(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(types.SteamInventoryReturn{Success: 0}); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	InitSteam("", "", server.URL, server.Client(), nil)
+
+	if _, err := getSteamInventoryItems(76561198000000000); err == nil {
+		t.Fatal("getSteamInventoryItems() error = nil, want error for unsuccessful response")
+	}
+}
+
+func TestGetItemMarketPriceTimeout(t *testing.T) {
+	resetSteam(t)
+
+	if priceClient.Timeout != 3*time.Second {
+		t.Fatalf("default price client timeout = %s, want 3s", priceClient.Timeout)
+	}
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// Only the price endpoint changes, so the default 3s price client is used.
+	InitSteam("", server.URL, "", nil, nil)
+
+	start := time.Now()
+
+	_, err := GetItemMarketPrice("Case A")
+	if err == nil {
+		t.Fatal("GetItemMarketPrice() error = nil, want timeout")
+	}
+
+	var netErr interface{ Timeout() bool }
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("GetItemMarketPrice() error = %v, want timeout error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 3*time.Second || elapsed > 5*time.Second {
+		t.Errorf("GetItemMarketPrice() returned after %s, want about 3s", elapsed)
+	}
+}
+
+func TestGetItemMarketPriceStatusErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   error
+	}{
+		{name: "rate limited", status: http.StatusTooManyRequests, want: ErrTooManyRequests},
+		{name: "not found", status: http.StatusInternalServerError, want: ErrItemNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSteam(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			InitSteam("", server.URL, "", nil, server.Client())
+
+			if _, err := GetItemMarketPrice("Case A"); !errors.Is(err, tt.want) {
+				t.Errorf("GetItemMarketPrice() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package steam
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Single entry of a storage unit export.
+type StorageItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Unit  string `json:"unit"`
+}
+
+// Loads a storage unit export, either a JSON array or a CSV file with name, count and unit columns.
+func LoadStorageUnitFile(path string) ([]StorageItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []StorageItem
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		body, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, err
+		}
+	case ".csv":
+		items, err = readStorageUnitCSV(f)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported storage unit file: %s, want .json or .csv", path)
+	}
+
+	for i, item := range items {
+		if item.Name == "" {
+			return nil, fmt.Errorf("missing item name in storage unit file (entry %d)", i+1)
+		}
+
+		if item.Count < 0 {
+			return nil, fmt.Errorf("negative count for %s in storage unit file", item.Name)
+		}
+
+		items[i].Name = strings.TrimSpace(item.Name)
+	}
+
+	return items, nil
+}
+
+// Counts storage unit items by name across all units.
+func CountStorageItems(items []StorageItem) map[string]int {
+	countMap := make(map[string]int)
+
+	for _, item := range items {
+		countMap[item.Name] += item.Count
+	}
+
+	return countMap
+}
+
+func readStorageUnitCSV(r io.Reader) ([]StorageItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []StorageItem
+
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("malformed storage unit csv line %d, want name,count[,unit]", i+1)
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			// Allow a header line.
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("malformed count on storage unit csv line %d: %s", i+1, record[1])
+		}
+
+		item := StorageItem{Name: record[0], Count: count}
+
+		if len(record) > 2 {
+			item.Unit = strings.TrimSpace(record[2])
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/system"
+	"github.com/devusSs/steamquery-v2/tables"
+)
+
+// Title of spreadsheets created by the init sheet command.
+const initSheetTitle = "steamquery-v2 Portfolio"
+
+// Creates or formats a spreadsheet with the default layout and writes a matching config.
+//
+// Settings of an existing config (auth, backend, Steam and watchdog) are kept in the new config.
+func runInitSheet(cfgPath, gCloudPath, spreadsheetID string, items int, outPath string) error {
+	if items < 1 {
+		return errors.New("init sheet needs room for at least 1 item")
+	}
+
+	if _, err := os.Stat(outPath); err == nil {
+		return fmt.Errorf("config file %s already exists, choose another path with -init-sheet-out", outPath)
+	}
+
+	cfg, err := config.LoadConfig(cfgPath)
+	if err != nil {
+		logging.LogWarning(
+			fmt.Sprintf("Could not load config %s, using defaults: %s", cfgPath, err.Error()),
+		)
+
+		cfg = &config.Config{}
+	}
+
+	layout := tables.NewSheetLayout(initSheetTitle, items)
+
+	var svc tables.SheetStore
+
+	switch {
+	case cfg.SheetBackend.IsLocal():
+		logging.LogInfo(fmt.Sprintf("Formatting local %s sheet %s", cfg.SheetBackend.Type, cfg.SheetBackend.Path))
+
+		svc, err = initSheetStore(cfg.SheetBackend, cfg.GoogleAuth, gCloudPath, "")
+	case spreadsheetID != "":
+		logging.LogInfo(fmt.Sprintf("Formatting spreadsheet %s", spreadsheetID))
+
+		cfg.SpreadSheetID = spreadsheetID
+
+		svc, err = initSheetStore(cfg.SheetBackend, cfg.GoogleAuth, gCloudPath, spreadsheetID)
+	default:
+		logging.LogInfo("Creating spreadsheet, please wait")
+
+		svc, err = createSpreadsheet(cfg, gCloudPath)
+	}
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	if err := svc.TestConnection(); err != nil {
+		return err
+	}
+
+	if err := tables.WriteSheetLayout(svc, layout); err != nil {
+		return err
+	}
+
+	logging.LogSuccess("Successfully wrote sheet layout")
+
+	cfg.ItemList = config.ItemList{
+		ColumnLetter: layout.ItemColumn,
+		StartNumber:  layout.StartRow,
+		EndNumber:    layout.EndRow,
+	}
+	cfg.PriceColumn = layout.PriceColumn
+	cfg.PriceTotalColumn = layout.PriceTotalColumn
+	cfg.AmountColumn = layout.AmountColumn
+	// The layout columns are set by letter.
+	cfg.ColumnHeaders = config.ColumnHeaders{}
+	cfg.OrgCells = config.OrgCells{
+		LastUpdatedCell: layout.LastUpdatedCell,
+		ErrorCell:       layout.ErrorCell,
+		TotalValueCell:  layout.TotalValueCell,
+		DifferenceCell:  layout.DifferenceCell,
+	}
+
+	if err := config.WriteConfig(cfg, outPath); err != nil {
+		return err
+	}
+
+	logging.LogSuccess(fmt.Sprintf("Wrote matching config to %s", outPath))
+
+	logging.LogInfo(
+		fmt.Sprintf(
+			"Enter your item names in column %s and amounts in column %s (rows %d to %d), fill in your Steam details in the config and run the program with -c %s",
+			layout.ItemColumn,
+			layout.AmountColumn,
+			layout.StartRow,
+			layout.EndRow,
+			outPath,
+		),
+	)
+
+	return nil
+}
+
+// Helper function which creates a new Google spreadsheet and stores its id in the config.
+func createSpreadsheet(cfg *config.Config, gCloudPath string) (tables.SheetStore, error) {
+	if cfg.GoogleAuth.UsesCredentialsFile() {
+		if err := system.CheckForGCloudConfigFile(gCloudPath); err != nil {
+			return nil, err
+		}
+	}
+
+	svc, err := tables.CreateSpreadsheet(getAuthConfig(cfg.GoogleAuth, gCloudPath), initSheetTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.SpreadSheetID = svc.SpreadsheetID()
+
+	logging.LogSuccess(
+		fmt.Sprintf("Created spreadsheet https://docs.google.com/spreadsheets/d/%s", cfg.SpreadSheetID),
+	)
+
+	if cfg.GoogleAuth.Type != tables.AuthOAuth {
+		logging.LogWarning(
+			"The spreadsheet is owned by your service account, share it with your Google account or create a spreadsheet yourself and use -init-sheet-id",
+		)
+	}
+
+	return svc, nil
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"runtime"
-	"strings"
 	"time"
 
 	"github.com/common-nighthawk/go-figure"
@@ -13,13 +14,21 @@ import (
 	"github.com/devusSs/steamquery-v2/config"
 	"github.com/devusSs/steamquery-v2/logging"
 	"github.com/devusSs/steamquery-v2/query"
+	"github.com/devusSs/steamquery-v2/sheetref"
 	"github.com/devusSs/steamquery-v2/statistics"
+	"github.com/devusSs/steamquery-v2/steam"
 	"github.com/devusSs/steamquery-v2/system"
 	"github.com/devusSs/steamquery-v2/tables"
 	"github.com/devusSs/steamquery-v2/updater"
 	"github.com/devusSs/steamquery-v2/utils"
 )
 
+// Default timeouts in seconds for Steam and Github requests if none are specified in the config.
+const (
+	defaultHTTPTimeout  = 10
+	defaultPriceTimeout = 3
+)
+
 // The maximum price items are allowed to drop (in total) before the app sends a warning mail.
 //
 // This will only work in watchdog mode.
@@ -35,10 +44,42 @@ func main() {
 	logDirFlag := flag.String("l", "./logs", "sets the logging directory")
 	disableUpdatesFlag := flag.Bool("du", false, "disables update check on startup")
 	analysisModeFlag := flag.Bool("a", false, "runs the app in analysis mode and exits")
-	skipChecks := flag.Bool("sc", false, "skips last updated and error cell checks on sheets")
+	skipChecks := flag.Bool("sc", false, "skips last run and last error cooldown checks")
 	betaFeatures := flag.Bool("b", false, "enables beta features, not recommended")
+	confirmSync := flag.Bool("yes", false, "applies inventory sync changes without confirmation")
+	storageFile := flag.String(
+		"storage",
+		"",
+		"path for a storage unit export (csv or json) to merge with the inventory",
+	)
+	sheetFile := flag.String(
+		"sheetfile",
+		"",
+		"path for a local JSON sheet file used instead of Google Sheets, useful for testing",
+	)
 	watchDog := flag.Bool("w", false, "enables watchdog mode with specified interval")
 	analysisFlag := flag.Bool("z", false, "performs data analysis for prices and exits")
+	statusReportFlag := flag.Bool(
+		"sr",
+		false,
+		"prints a Steam status report, writes an availability chart and exits",
+	)
+	initSheetFlag := flag.Bool(
+		"init-sheet",
+		false,
+		"creates or formats a spreadsheet with the expected layout, writes a matching config and exits",
+	)
+	initSheetID := flag.String(
+		"init-sheet-id",
+		"",
+		"spreadsheet id to format with -init-sheet instead of creating a new spreadsheet",
+	)
+	initSheetItems := flag.Int("init-sheet-items", 23, "number of item rows created by -init-sheet")
+	initSheetOut := flag.String(
+		"init-sheet-out",
+		"./files/config.init.json",
+		"path the config created by -init-sheet gets written to",
+	)
 	envFlag := flag.Bool("e", false, "uses env instead of config file, useful for docker")
 	envFile := flag.String(
 		"efile",
@@ -47,7 +88,7 @@ func main() {
 	)
 	flag.Parse()
 
-	alreadyRunning, err := system.CheckAlreadyRunning(*watchDog, *analysisFlag)
+	alreadyRunning, err := system.CheckAlreadyRunning(*watchDog, *analysisFlag || *statusReportFlag)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -58,16 +99,40 @@ func main() {
 	}
 
 	if *analysisModeFlag {
-		if err := system.RunAnalysisMode(*logDirFlag, *cfgPathFlag, *gCloudPathFlag); err != nil {
-			log.Fatalf("Error running analysis mode: %s\n", err.Error())
+		// The sheet check uses the loggers of the sheet stores.
+		if err := logging.CreateLogsDirectory(*logDirFlag); err != nil {
+			log.Fatalf("Error creating logs directory: %s\n", err.Error())
 		}
-		return
-	}
 
-	if !*disableUpdatesFlag {
-		if err := updater.CheckForUpdatesAndApply(); err != nil {
-			log.Fatalf("Error checking for updates: %s\n", err.Error())
+		logLevel := "release"
+		if *debugFlag {
+			logLevel = "dev"
 		}
+
+		if err := logging.InitLoggers(logLevel); err != nil {
+			log.Fatalf("Error initiating loggers: %s\n", err.Error())
+		}
+
+		if !*disableUpdatesFlag {
+			// The config gets checked by analysis mode, a broken one keeps the default endpoints.
+			if cfg, err := config.LoadConfig(*cfgPathFlag); err == nil {
+				initEndpoints(cfg.Endpoints)
+			}
+
+			if err := updater.CheckForUpdatesAndApply(); err != nil {
+				log.Fatalf("Error checking for updates: %s\n", err.Error())
+			}
+		}
+
+		if err := system.RunAnalysisMode(
+			*logDirFlag,
+			*cfgPathFlag,
+			*gCloudPathFlag,
+			openAnalysisSheet(*gCloudPathFlag),
+		); err != nil {
+			log.Fatalf("Error running analysis mode: %s\n", err.Error())
+		}
+		return
 	}
 
 	if err := updater.CheckMinVersion(); err != nil {
@@ -92,7 +157,21 @@ func main() {
 		}
 	}
 
-	if *analysisFlag {
+	if *initSheetFlag {
+		if err := runInitSheet(
+			*cfgPathFlag,
+			*gCloudPathFlag,
+			*initSheetID,
+			*initSheetItems,
+			*initSheetOut,
+		); err != nil {
+			logging.LogFatal(err.Error())
+		}
+
+		return
+	}
+
+	if *analysisFlag || *statusReportFlag {
 		cfg, err := config.LoadConfig(*cfgPathFlag)
 		if err != nil {
 			logging.LogFatal(err.Error())
@@ -102,6 +181,28 @@ func main() {
 			logging.LogFatal(err.Error())
 		}
 
+		initEndpoints(cfg.Endpoints)
+
+		if !*disableUpdatesFlag {
+			if err := updater.CheckForUpdatesAndApply(); err != nil {
+				logging.LogFatal(fmt.Sprintf("Error checking for updates: %s", err.Error()))
+			}
+		}
+
+		if *statusReportFlag {
+			if *watchDog {
+				statistics.StartSteamStatusReport(
+					&cfg.WatchDog.Postgres,
+					*logDirFlag,
+					statistics.DBPostgres,
+				)
+			} else {
+				statistics.StartSteamStatusReport(&cfg.WatchDog.Postgres, *logDirFlag, statistics.DBSQLite)
+			}
+
+			return
+		}
+
 		if *watchDog {
 			statistics.StartStatsAnalysis(
 				&cfg.WatchDog.Postgres,
@@ -148,15 +249,31 @@ func main() {
 		}
 	}
 
+	if *sheetFile != "" {
+		cfg.SheetBackend = config.SheetBackend{Type: "json", Path: *sheetFile}
+	}
+
 	if err := cfg.CheckConfig(*watchDog); err != nil {
 		logging.LogFatal(err.Error())
 	}
 
-	if err := system.CheckForGCloudConfigFile(*gCloudPathFlag); err != nil {
-		logging.LogFatal(err.Error())
+	initEndpoints(cfg.Endpoints)
+
+	if !*disableUpdatesFlag {
+		if err := updater.CheckForUpdatesAndApply(); err != nil {
+			logging.LogFatal(fmt.Sprintf("Error checking for updates: %s", err.Error()))
+		}
 	}
 
-	svc, err := tables.NewSpreadsheetService(*gCloudPathFlag, cfg.SpreadSheetID)
+	tables.SetRequestPolicy(tables.RequestPolicy{
+		MaxRetries:        cfg.GoogleAPI.MaxRetries,
+		RequestsPerMinute: cfg.GoogleAPI.RequestsPerMinute,
+	})
+
+	// Nobody can open the login URL of unattended runs.
+	tables.SetOAuthLogin(!*watchDog && !*envFlag)
+
+	svc, err := initSheetStore(cfg.SheetBackend, cfg.GoogleAuth, *gCloudPathFlag, cfg.SpreadSheetID)
 	if err != nil {
 		logging.LogFatal(err.Error())
 	}
@@ -165,18 +282,47 @@ func main() {
 		logging.LogFatal(err.Error())
 	}
 
-	query.InitQuery(
+	if err := checkSheetReferences(svc, cfg); err != nil {
+		logging.LogFatal(err.Error())
+	}
+
+	if err := query.InitQuery(
 		svc,
 		cfg.ItemList,
 		cfg.PriceColumn,
 		cfg.PriceTotalColumn,
 		cfg.AmountColumn,
+		cfg.ColumnHeaders,
+		cfg.MetadataColumns,
+		cfg.Categories,
+		cfg.TradeHolds,
+		cfg.PriceFormatting,
+		cfg.PriceHistory,
 		cfg.OrgCells,
 		cfg.SteamAPIKey,
-		cfg.SteamUserID64,
+		cfg.GetSteamAccounts(),
+		getPortfolioID(cfg),
+		cfg.RunCooldown,
 		*skipChecks,
 		*betaFeatures,
-	)
+		*confirmSync,
+		*watchDog,
+		*storageFile,
+		cfg.SteamHealth.SkipForPriceRuns,
+	); err != nil {
+		logging.LogFatal(err.Error())
+	}
+
+	steam.SetHealthPolicy(steam.HealthPolicy{
+		RequiredServices: cfg.SteamHealth.RequiredServices,
+		DelayedIsUp:      !cfg.SteamHealth.TreatDelayedAsDown,
+	})
+
+	retryPolicy := query.NewSteamRetryPolicy(cfg.WatchDog)
+
+	if cfg.WatchDog.OverlapPolicy != "" {
+		query.SetOverlapPolicy(query.OverlapPolicy(cfg.WatchDog.OverlapPolicy))
+	}
 
 	logging.LogInfo("Running statistics setup, please wait")
 
@@ -224,13 +370,9 @@ func main() {
 		stopRerun := make(chan bool)
 
 		// Run the app once and the on every tick.
-		priceDifference, err := query.RunQuery(cfg.WatchDog.SteamRetryInterval)
+		priceDifference, err := query.RunQuery(retryPolicy)
 		if err != nil {
-			if strings.Contains(err.Error(), "last run has been less than 3 minutes ago") {
-				logging.LogFatal(err.Error())
-			}
-
-			if strings.Contains(err.Error(), "last error has been less than 3 minutes ago") {
+			if errors.Is(err, query.ErrCooldownActive) {
 				logging.LogFatal(err.Error())
 			}
 
@@ -249,6 +391,10 @@ func main() {
 			mailData := utils.EmailData{}
 			mailData.Subject = "steamquery-v2 run failed"
 			mailData.Data = utils.GenerateFailRunSummary(err)
+			if errors.Is(err, query.ErrSteamUnavailable) {
+				mailData.Subject = "steamquery-v2 gave up waiting for Steam"
+				mailData.Data = utils.GenerateSteamUnavailableSummary(err)
+			}
 			if err := utils.SendMail(&mailData); err != nil {
 				logging.LogFatal(err.Error())
 			}
@@ -271,7 +417,7 @@ func main() {
 		} else {
 			mailData := utils.EmailData{}
 			mailData.Subject = "steamquery-v2 run summary"
-			mailData.Data = utils.GenerateRunSummary(priceDifference)
+			mailData.Data = generateRunSummary(priceDifference)
 			if err := utils.SendMail(&mailData); err != nil {
 				logging.LogFatal(err.Error())
 			}
@@ -291,57 +437,18 @@ func main() {
 				case <-stopRerun:
 					return
 				case <-rerunticker.C:
-					if !query.QueryRunning {
-						priceDifference, err := query.RunQuery(
-							cfg.WatchDog.SteamRetryInterval,
-						)
-						if err != nil {
-							if err := query.WriteErrorCell(fmt.Errorf("%s (TS: %s)", err.Error(), time.Now().Local().Format("2006-01-02 15:04:05 CEST"))); err != nil {
-								logging.LogFatal(err.Error())
-							}
-
-							mailData := utils.EmailData{}
-							mailData.Subject = "steamquery-v2 run failed"
-							mailData.Data = utils.GenerateFailRunSummary(err)
-							if err := utils.SendMail(&mailData); err != nil {
-								logging.LogFatal(err.Error())
-							}
-						}
-
-						if priceDifference < (maxPriceDifference * -1) {
-							mailData := utils.EmailData{}
-							mailData.Subject = "steamquery-v2 price drop alert"
-							mailData.Data = utils.GeneratePriceDropWarning(priceDifference)
-							if err := utils.SendMail(&mailData); err != nil {
-								logging.LogFatal(err.Error())
-							}
-						} else {
-							mailData := utils.EmailData{}
-							mailData.Subject = "steamquery-v2 run summary"
-							mailData.Data = utils.GenerateRunSummary(priceDifference)
-							if err := utils.SendMail(&mailData); err != nil {
-								logging.LogFatal(err.Error())
-							}
-						}
-
-						system.PrintBytesUsed()
-						system.BytesUsed = 0
-
-						system.Clear[runtime.GOOS]()
-						logging.LogSuccess("Query run completed")
-						logging.LogInfo(
-							fmt.Sprintf(
-								"Running query again in %d hour(s)",
-								cfg.WatchDog.RetryInterval,
-							),
-						)
-						logging.LogInfo("Press CTRL+C to exit")
-					}
+					// Dispatch the run so overlapping triggers are handled by the overlap policy.
+					go runWatchdogQuery(retryPolicy, cfg.WatchDog.RetryInterval)
 				}
 			}
 		}()
 
 		system.ListenForCTRLC()
+
+		if state := query.CurrentState(); state.Status == query.StatusRunning {
+			logging.LogWarning(fmt.Sprintf("Exiting during query run: %s", state))
+		}
+
 		rerunticker.Stop()
 		stopRerun <- true
 		stopUpdatesCheck <- true
@@ -350,12 +457,8 @@ func main() {
 			logging.LogFatal(err.Error())
 		}
 	} else {
-		if _, err := query.RunQuery(cfg.WatchDog.SteamRetryInterval); err != nil {
-			if strings.Contains(err.Error(), "last run has been less than 3 minutes ago") {
-				logging.LogFatal(err.Error())
-			}
-
-			if strings.Contains(err.Error(), "last error has been less than 3 minutes ago") {
+		if _, err := query.RunQuery(retryPolicy); err != nil {
+			if errors.Is(err, query.ErrCooldownActive) {
 				logging.LogFatal(err.Error())
 			}
 
@@ -377,6 +480,10 @@ func main() {
 		}
 	}
 
+	if err := svc.Close(); err != nil {
+		logging.LogError(fmt.Sprintf("Error closing sheet: %s", err.Error()))
+	}
+
 	system.PrintBytesUsed()
 
 	logging.LogSuccess("Done, exiting app now")
@@ -391,7 +498,227 @@ func main() {
 	}
 }
 
+// Applies the configured endpoints and HTTP clients for all Steam and Github calls.
+func initEndpoints(endpoints config.Endpoints) {
+	steamTimeout := endpoints.SteamTimeout
+	if steamTimeout == 0 {
+		steamTimeout = defaultHTTPTimeout
+	}
+
+	priceTimeout := endpoints.SteamPriceTimeout
+	if priceTimeout == 0 {
+		priceTimeout = defaultPriceTimeout
+	}
+
+	githubTimeout := endpoints.GithubTimeout
+	if githubTimeout == 0 {
+		githubTimeout = defaultHTTPTimeout
+	}
+
+	steam.InitSteam(
+		endpoints.SteamStatusURL,
+		endpoints.SteamPriceURL,
+		endpoints.SteamInventoryURL,
+		&http.Client{Timeout: time.Duration(steamTimeout) * time.Second},
+		&http.Client{Timeout: time.Duration(priceTimeout) * time.Second},
+	)
+
+	updater.InitUpdater(
+		endpoints.GithubReleaseURL,
+		&http.Client{Timeout: time.Duration(githubTimeout) * time.Second},
+	)
+}
+
+// Generates the run summary including the inventory changes if the run fetched the inventory.
+func generateRunSummary(priceDifference float64) string {
+	summary := utils.GenerateRunSummary(priceDifference)
+
+	if categoryTotals := query.GetCategoryTotals(); len(categoryTotals) > 0 {
+		var names []string
+		totals := make(map[string]float64)
+		differences := make(map[string]float64)
+
+		for _, category := range categoryTotals {
+			names = append(names, category.Name)
+			totals[category.Name] = category.Total
+			differences[category.Name] = category.Difference
+		}
+
+		summary += utils.GenerateCategoryBreakdown(names, totals, differences)
+	}
+
+	if values := query.GetHoldValues(); values != nil {
+		summary += utils.GenerateHoldValues(values.Liquid, values.Locked)
+	}
+
+	if report := query.GetInventoryReport(); report != nil {
+		summary += utils.GenerateInventoryReport(
+			report.From,
+			report.To,
+			report.Acquired,
+			report.Sold,
+			report.MovedToStorage,
+			report.MovedFromStorage,
+		)
+	}
+
+	return summary
+}
+
+// Runs the query on a watchdog tick and sends the corresponding mails.
+func runWatchdogQuery(retryPolicy query.SteamRetryPolicy, retryInterval int) {
+	logging.LogDebug(fmt.Sprintf("QUERY STATE PRE RUN: %s", query.CurrentState()))
+
+	priceDifference, err := query.RunQuery(retryPolicy)
+	if err != nil {
+		if errors.Is(err, query.ErrRunInProgress) || errors.Is(err, query.ErrCooldownActive) {
+			logging.LogWarning(fmt.Sprintf("Skipping watchdog run: %s", err.Error()))
+			return
+		}
+
+		// Keeps the watchdog running if the sheet is not reachable, the mail still reports the error.
+		if err := query.WriteErrorCell(fmt.Errorf("%s (TS: %s)", err.Error(), time.Now().Local().Format("2006-01-02 15:04:05 CEST"))); err != nil {
+			logging.LogError(err.Error())
+		}
+
+		mailData := utils.EmailData{}
+		mailData.Subject = "steamquery-v2 run failed"
+		mailData.Data = utils.GenerateFailRunSummary(err)
+		if errors.Is(err, query.ErrSteamUnavailable) {
+			mailData.Subject = "steamquery-v2 gave up waiting for Steam"
+			mailData.Data = utils.GenerateSteamUnavailableSummary(err)
+		}
+		if err := utils.SendMail(&mailData); err != nil {
+			logging.LogFatal(err.Error())
+		}
+
+		return
+	}
+
+	if priceDifference < (maxPriceDifference * -1) {
+		mailData := utils.EmailData{}
+		mailData.Subject = "steamquery-v2 price drop alert"
+		mailData.Data = utils.GeneratePriceDropWarning(priceDifference)
+		if err := utils.SendMail(&mailData); err != nil {
+			logging.LogFatal(err.Error())
+		}
+	} else {
+		mailData := utils.EmailData{}
+		mailData.Subject = "steamquery-v2 run summary"
+		mailData.Data = generateRunSummary(priceDifference)
+		if err := utils.SendMail(&mailData); err != nil {
+			logging.LogFatal(err.Error())
+		}
+	}
+
+	system.PrintBytesUsed()
+	system.BytesUsed = 0
+
+	system.Clear[runtime.GOOS]()
+	logging.LogSuccess("Query run completed")
+	logging.LogInfo(
+		fmt.Sprintf(
+			"Running query again in %d hour(s)",
+			retryInterval,
+		),
+	)
+	logging.LogInfo("Press CTRL+C to exit")
+}
+
 func printAsciiArt() {
 	asciiArt := figure.NewColorFigure("steamquery v2", "small", "green", true)
 	asciiArt.Print()
 }
+
+// Creates the sheet store for the configured backend, Google Sheets by default.
+func initSheetStore(
+	backend config.SheetBackend,
+	auth config.GoogleAuth,
+	gCloudPath string,
+	spreadsheetID string,
+) (tables.SheetStore, error) {
+	if backend.IsLocal() {
+		logging.LogWarning(
+			fmt.Sprintf("Using local %s sheet %s instead of Google Sheets", backend.Type, backend.Path),
+		)
+	}
+
+	switch backend.Type {
+	case "json":
+		return tables.NewFileSheetStore(backend.Path)
+	case "csv":
+		return tables.NewCSVSheetStore(backend.Path)
+	case "xlsx":
+		return tables.NewXLSXSheetStore(backend.Path, backend.SheetName)
+	default:
+		if auth.UsesCredentialsFile() {
+			if err := system.CheckForGCloudConfigFile(gCloudPath); err != nil {
+				return nil, err
+			}
+		}
+
+		return tables.NewSpreadsheetService(getAuthConfig(auth, gCloudPath), spreadsheetID)
+	}
+}
+
+// Returns the sheet opener of analysis mode, using the same backend and auth as a run.
+func openAnalysisSheet(gCloudPath string) system.SheetOpener {
+	return func(cfg *config.Config) (tables.SheetStore, error) {
+		tables.SetRequestPolicy(tables.RequestPolicy{
+			MaxRetries:        cfg.GoogleAPI.MaxRetries,
+			RequestsPerMinute: cfg.GoogleAPI.RequestsPerMinute,
+		})
+
+		return initSheetStore(cfg.SheetBackend, cfg.GoogleAuth, gCloudPath, cfg.SpreadSheetID)
+	}
+}
+
+// Checks the configured references against the sheet and resolves a named item list.
+func checkSheetReferences(svc tables.SheetStore, cfg *config.Config) error {
+	if err := svc.CheckReferences(cfg.GetSheetReferences()); err != nil {
+		return err
+	}
+
+	if !cfg.ItemList.IsNamedRange() {
+		return nil
+	}
+
+	resolver, ok := svc.(tables.NamedRangeResolver)
+	if !ok {
+		return fmt.Errorf("named range %s is only supported by Google Sheets", cfg.ItemList.ColumnLetter)
+	}
+
+	itemRange, err := resolver.ResolveNamedRange(cfg.ItemList.ColumnLetter)
+	if err != nil {
+		return err
+	}
+
+	column, startNumber, endNumber, err := sheetref.ParseColumnRange(itemRange)
+	if err != nil {
+		return err
+	}
+
+	logging.LogDebug(fmt.Sprintf("ITEM LIST %s: %s", cfg.ItemList.ColumnLetter, itemRange))
+
+	return cfg.SetItemList(column, startNumber, endNumber)
+}
+
+// Converts the Google auth config for the tables package, the credentials file is set via -g.
+func getAuthConfig(auth config.GoogleAuth, gCloudPath string) tables.AuthConfig {
+	return tables.AuthConfig{
+		Type:             auth.Type,
+		CredentialsFile:  gCloudPath,
+		CredentialsEnv:   auth.CredentialsEnv,
+		ClientSecretFile: auth.ClientSecretFile,
+		TokenFile:        auth.TokenFile,
+	}
+}
+
+// Returns the id the local run state is stored under, the spreadsheet id or the local sheet path.
+func getPortfolioID(cfg *config.Config) string {
+	if cfg.SheetBackend.IsLocal() {
+		return cfg.SheetBackend.Path
+	}
+
+	return cfg.SpreadSheetID
+}
@@ -0,0 +1,257 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/devusSs/steamquery-v2/config"
+	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/sheetref"
+	"github.com/devusSs/steamquery-v2/tables"
+)
+
+// Opens the sheet store of a config, set by the caller since it depends on the auth flags.
+type SheetOpener func(c *config.Config) (tables.SheetStore, error)
+
+// Function connects to the configured sheet and checks the cells a run reads and writes.
+//
+// Every problem gets printed with its cell reference, the returned error only counts them.
+func checkSheetSchema(c *config.Config, openSheet SheetOpener) error {
+	fmt.Printf("%s Connecting to sheet\n", logging.InfSign)
+
+	svc, err := openSheet(c)
+	if err != nil {
+		return err
+	}
+	defer svc.Close()
+
+	if err := svc.TestConnection(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Successfully connected to sheet\n", logging.SucSign)
+
+	fmt.Printf("%s Checking sheet schema\n", logging.InfSign)
+
+	var problems []string
+
+	missing := make(map[string]bool)
+
+	for _, ref := range c.GetSheetReferences() {
+		if err := svc.CheckReferences([]string{ref}); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", ref, err.Error()))
+			missing[ref] = true
+		}
+	}
+
+	itemColumn, amountColumn, err := getSchemaColumns(c, svc)
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		problems = append(problems, checkItemRows(c, svc, itemColumn, amountColumn)...)
+	}
+
+	problems = append(problems, checkOrgCellsWritable(c, svc, missing)...)
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Printf("%s %s\n", logging.ErrSign, problem)
+		}
+
+		return fmt.Errorf("found %d problem(s) in your sheet", len(problems))
+	}
+
+	fmt.Printf("%s Successfully checked sheet schema\n", logging.SucSign)
+
+	return nil
+}
+
+// Helper function which returns the item and amount column, resolving named ranges and headers.
+func getSchemaColumns(c *config.Config, svc tables.SheetStore) (string, string, error) {
+	if c.ItemList.IsNamedRange() {
+		resolver, ok := svc.(tables.NamedRangeResolver)
+		if !ok {
+			return "", "", fmt.Errorf("%s: named ranges are only supported by Google Sheets", c.ItemList.ColumnLetter)
+		}
+
+		itemRange, err := resolver.ResolveNamedRange(c.ItemList.ColumnLetter)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %s", c.ItemList.ColumnLetter, err.Error())
+		}
+
+		column, startNumber, endNumber, err := sheetref.ParseColumnRange(itemRange)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %s", c.ItemList.ColumnLetter, err.Error())
+		}
+
+		if err := c.SetItemList(column, startNumber, endNumber); err != nil {
+			return "", "", fmt.Errorf("%s: %s", itemRange, err.Error())
+		}
+	}
+
+	tab, _ := sheetref.SplitReference(c.ItemList.ColumnLetter)
+
+	itemColumn := c.ItemList.ColumnLetter
+	amountColumn := c.AmountColumn
+
+	if c.ColumnHeaders.Item != "" || c.ColumnHeaders.Amount != "" {
+		var headers []string
+		for _, header := range []string{c.ColumnHeaders.Item, c.ColumnHeaders.Amount} {
+			if header != "" {
+				headers = append(headers, header)
+			}
+		}
+
+		resolved, err := tables.ResolveColumnHeaders(svc, tab, c.ColumnHeaders.HeaderRow, headers)
+		if err != nil {
+			return "", "", fmt.Errorf(
+				"%s: %s",
+				sheetref.JoinReference(tab, fmt.Sprintf("%d:%d", c.ColumnHeaders.HeaderRow, c.ColumnHeaders.HeaderRow)),
+				err.Error(),
+			)
+		}
+
+		if c.ColumnHeaders.Item != "" {
+			itemColumn = sheetref.JoinReference(tab, resolved[c.ColumnHeaders.Item])
+		}
+
+		if c.ColumnHeaders.Amount != "" {
+			amountColumn = sheetref.JoinReference(tab, resolved[c.ColumnHeaders.Amount])
+		}
+	}
+
+	if amountColumn != "" && !strings.Contains(amountColumn, "!") {
+		amountColumn = sheetref.JoinReference(tab, amountColumn)
+	}
+
+	return itemColumn, amountColumn, nil
+}
+
+// Helper function which checks the item rows have names and whole number amounts.
+func checkItemRows(c *config.Config, svc tables.SheetStore, itemColumn, amountColumn string) []string {
+	startNumber := c.ItemList.StartNumber
+	endNumber := c.ItemList.EndNumber
+
+	items, err := getColumnCells(svc, itemColumn, startNumber, endNumber)
+	if err != nil {
+		return []string{fmt.Sprintf("%s%d:%s%d: %s", itemColumn, startNumber, itemColumn, endNumber, err.Error())}
+	}
+
+	amounts, err := getColumnCells(svc, amountColumn, startNumber, endNumber)
+	if err != nil {
+		return []string{
+			fmt.Sprintf("%s%d:%s%d: %s", amountColumn, startNumber, amountColumn, endNumber, err.Error()),
+		}
+	}
+
+	var problems []string
+
+	names := 0
+
+	for row := startNumber; row <= endNumber; row++ {
+		item := strings.TrimSpace(items[row])
+		amount := amounts[row]
+
+		if item != "" {
+			names++
+		}
+
+		if item == "" && amount != "" {
+			problems = append(
+				problems,
+				fmt.Sprintf("%s%d: missing item name for amount %q in %s%d", itemColumn, row, amount, amountColumn, row),
+			)
+		}
+
+		if amount == "" {
+			continue
+		}
+
+		if _, err := strconv.ParseInt(amount, 10, 64); err != nil {
+			problems = append(problems, fmt.Sprintf("%s%d: amount %q is not a whole number", amountColumn, row, amount))
+		}
+	}
+
+	// Runs fail without any amount.
+	if len(amounts) == 0 {
+		problems = append(
+			problems,
+			fmt.Sprintf("%s%d:%s%d: amount column has no amounts", amountColumn, startNumber, amountColumn, endNumber),
+		)
+	}
+
+	if names == 0 {
+		problems = append(
+			problems,
+			fmt.Sprintf("%s%d:%s%d: item list has no item names", itemColumn, startNumber, itemColumn, endNumber),
+		)
+	}
+
+	return problems
+}
+
+// Helper function which checks the org cells are writable.
+//
+// Only protected ranges and the file permissions of local sheets are checked, nothing gets written.
+// Missing cells are already reported and get skipped.
+func checkOrgCellsWritable(c *config.Config, svc tables.SheetStore, missing map[string]bool) []string {
+	var cells []string
+	for _, cell := range []string{
+		c.OrgCells.LastUpdatedCell,
+		c.OrgCells.ErrorCell,
+		c.OrgCells.TotalValueCell,
+		c.OrgCells.DifferenceCell,
+	} {
+		if !missing[cell] {
+			cells = append(cells, cell)
+		}
+	}
+
+	checker, ok := svc.(tables.ReadOnlyChecker)
+	if !ok || len(cells) == 0 {
+		return nil
+	}
+
+	readOnly, err := checker.GetReadOnlyCells(cells)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %s", strings.Join(cells, ", "), err.Error())}
+	}
+
+	var problems []string
+	for _, cell := range readOnly {
+		problems = append(problems, fmt.Sprintf("%s: cell is protected and not writable", cell))
+	}
+
+	return problems
+}
+
+// Helper function which returns the values of a column keyed by row, empty cells are missing.
+//
+// Values are kept as read since the query parses them without trimming.
+func getColumnCells(svc tables.SheetStore, column string, startNumber, endNumber int) (map[int]string, error) {
+	if column == "" {
+		return nil, errors.New("missing column")
+	}
+
+	values, err := svc.GetValuesForCells(
+		fmt.Sprintf("%s%d", column, startNumber),
+		fmt.Sprintf("%s%d", column, endNumber),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	cells := make(map[int]string)
+
+	for i, rowValues := range values {
+		if len(rowValues) == 0 {
+			continue
+		}
+
+		cells[startNumber+i] = fmt.Sprintf("%v", rowValues[0])
+	}
+
+	return cells, nil
+}
@@ -113,7 +113,7 @@ func CheckForGCloudConfigFile(path string) error {
 	return err
 }
 
-func RunAnalysisMode(logsDir, cfg, gCloud string) error {
+func RunAnalysisMode(logsDir, cfg, gCloud string, openSheet SheetOpener) error {
 	if err := readAndCheckErrorFile(logsDir); err != nil {
 		if strings.Contains(err.Error(), "no such file or directory") {
 			fmt.Printf("%s No error.log file so far\n", logging.SucSign)
@@ -134,11 +134,18 @@ func RunAnalysisMode(logsDir, cfg, gCloud string) error {
 		return err
 	}
 
-	if err := loadAndCheckConfig(cfg); err != nil {
+	c, err := loadAndCheckConfig(cfg)
+	if err != nil {
 		return err
 	}
 
-	if err := checkGCoudConfigFileExist(gCloud); err != nil {
+	if !c.SheetBackend.IsLocal() && c.GoogleAuth.UsesCredentialsFile() {
+		if err := checkGCoudConfigFileExist(gCloud); err != nil {
+			return err
+		}
+	}
+
+	if err := checkSheetSchema(c, openSheet); err != nil {
 		return err
 	}
 
@@ -289,12 +296,12 @@ func checkGCoudConfigFileExist(gCloud string) error {
 	return nil
 }
 
-func loadAndCheckConfig(cfg string) error {
+func loadAndCheckConfig(cfg string) (*config.Config, error) {
 	fmt.Printf("%s Attempting to load config\n", logging.InfSign)
 
 	c, err := config.LoadConfig(cfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	fmt.Printf("%s Successfully loaded config\n", logging.SucSign)
@@ -302,10 +309,10 @@ func loadAndCheckConfig(cfg string) error {
 	fmt.Printf("%s Checking config\n", logging.InfSign)
 
 	if err := c.CheckConfig(false); err != nil {
-		return err
+		return nil, err
 	}
 
 	fmt.Printf("%s Successfully checked config\n", logging.SucSign)
 
-	return nil
+	return c, nil
 }
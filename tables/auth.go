@@ -0,0 +1,296 @@
+package tables
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	sheets "google.golang.org/api/sheets/v4"
+
+	"github.com/devusSs/steamquery-v2/logging"
+)
+
+// Supported Google authentication types.
+const (
+	AuthFile  = "file"
+	AuthADC   = "adc"
+	AuthEnv   = "env"
+	AuthOAuth = "oauth"
+)
+
+// Defaults used if the corresponding values are not specified.
+const (
+	DefaultCredentialsEnv = "GOOGLE_CREDENTIALS"
+	DefaultTokenFile      = "./files/token.json"
+)
+
+// Time the user has to finish the OAuth login in the browser.
+const oauthLoginTimeout = 5 * time.Minute
+
+// Disabled for unattended runs (watchdog, Docker) which can not open the login URL.
+var oauthLoginEnabled = true
+
+// Enables or disables the OAuth browser login, without it a missing token file is an error.
+func SetOAuthLogin(enabled bool) {
+	oauthLoginEnabled = enabled
+}
+
+// Google authentication settings.
+//
+// File uses the service account CredentialsFile, ADC the application default credentials,
+// Env a service account JSON (optionally base64 encoded) from the CredentialsEnv variable
+// and OAuth a user login with the installed app ClientSecretFile, cached in TokenFile.
+type AuthConfig struct {
+	Type             string
+	CredentialsFile  string
+	CredentialsEnv   string
+	ClientSecretFile string
+	TokenFile        string
+}
+
+// Helper function which returns the client option authenticating the Sheets service.
+func getAuthOption(ctx context.Context, auth AuthConfig) (option.ClientOption, error) {
+	switch auth.Type {
+	case "", AuthFile:
+		return option.WithCredentialsFile(auth.CredentialsFile), nil
+	case AuthADC:
+		credentials, err := google.FindDefaultCredentials(ctx, sheets.SpreadsheetsScope)
+		if err != nil {
+			return nil, fmt.Errorf("could not find application default credentials: %s", err.Error())
+		}
+
+		return option.WithCredentials(credentials), nil
+	case AuthEnv:
+		credentials, err := getEnvCredentials(auth.CredentialsEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		return option.WithCredentialsJSON(credentials), nil
+	case AuthOAuth:
+		tokenSource, err := getOAuthTokenSource(ctx, auth.ClientSecretFile, auth.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return option.WithTokenSource(tokenSource), nil
+	default:
+		return nil, fmt.Errorf("unsupported google auth type: %s", auth.Type)
+	}
+}
+
+// Helper function which reads the credentials JSON from the env variable, plain or base64 encoded.
+func getEnvCredentials(name string) ([]byte, error) {
+	if name == "" {
+		name = DefaultCredentialsEnv
+	}
+
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return nil, fmt.Errorf("missing google credentials in env variable %s", name)
+	}
+
+	if strings.HasPrefix(value, "{") {
+		return []byte(value), nil
+	}
+
+	credentials, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("google credentials in env variable %s are neither JSON nor base64", name)
+	}
+
+	return credentials, nil
+}
+
+// Helper function which returns a token source for the OAuth user login.
+//
+// The token gets loaded from the token file, the login only runs if there is none yet.
+func getOAuthTokenSource(
+	ctx context.Context,
+	clientSecretFile string,
+	tokenFile string,
+) (oauth2.TokenSource, error) {
+	if tokenFile == "" {
+		tokenFile = DefaultTokenFile
+	}
+
+	clientSecret, err := os.ReadFile(clientSecretFile)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthConfig, err := google.ConfigFromJSON(clientSecret, sheets.SpreadsheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("malformed client secret file %s: %s", clientSecretFile, err.Error())
+	}
+
+	token, err := loadToken(tokenFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if !oauthLoginEnabled {
+			return nil, fmt.Errorf(
+				"missing google token file %s, log in once without watchdog or docker to create it",
+				tokenFile,
+			)
+		}
+
+		token, err = runOAuthLogin(ctx, oauthConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := saveToken(tokenFile, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cachedTokenSource{
+		source: oauthConfig.TokenSource(ctx, token),
+		path:   tokenFile,
+		token:  token,
+	}, nil
+}
+
+// Helper function which runs the installed app login with a redirect to a local port.
+//
+// The redirect only reaches the machine running the program, the browser needs to run there too.
+func runOAuthLogin(ctx context.Context, oauthConfig *oauth2.Config) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	oauthConfig.RedirectURL = fmt.Sprintf("http://%s/", listener.Addr().String())
+
+	state, err := getOAuthState()
+	if err != nil {
+		return nil, err
+	}
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("state") != state {
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			}
+
+			code := r.URL.Query().Get("code")
+			if code == "" {
+				http.Error(w, "missing code", http.StatusBadRequest)
+				errs <- fmt.Errorf("google login failed: %s", r.URL.Query().Get("error"))
+				return
+			}
+
+			fmt.Fprintln(w, "Login successful, you may close this window now.")
+			codes <- code
+		}),
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+	defer server.Close()
+
+	logging.LogInfo(
+		fmt.Sprintf(
+			"Open the following URL in your browser to log in to Google:\n%s",
+			oauthConfig.AuthCodeURL(state, oauth2.AccessTypeOffline),
+		),
+	)
+
+	select {
+	case code := <-codes:
+		return oauthConfig.Exchange(ctx, code)
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(oauthLoginTimeout):
+		return nil, fmt.Errorf("google login did not finish within %v", oauthLoginTimeout)
+	}
+}
+
+// Helper function which returns a random state protecting the login redirect.
+func getOAuthState() (string, error) {
+	state := make([]byte, 16)
+
+	if _, err := rand.Read(state); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(state), nil
+}
+
+func loadToken(path string) (*oauth2.Token, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var token oauth2.Token
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("malformed token file %s: %s", path, err.Error())
+	}
+
+	return &token, nil
+}
+
+// The token grants access to your sheets, so only the owner may read it.
+func saveToken(path string, token *oauth2.Token) error {
+	body, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, body, 0o600)
+}
+
+// Token source which writes refreshed tokens back to the token file.
+type cachedTokenSource struct {
+	mu     sync.Mutex
+	source oauth2.TokenSource
+	path   string
+	token  *oauth2.Token
+}
+
+func (c *cachedTokenSource) Token() (*oauth2.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, err := c.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if token.AccessToken != c.token.AccessToken {
+		if err := saveToken(c.path, token); err != nil {
+			logging.LogWarning(fmt.Sprintf("Could not cache google token: %s", err.Error()))
+		}
+
+		c.token = token
+	}
+
+	return token, nil
+}
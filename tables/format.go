@@ -0,0 +1,82 @@
+package tables
+
+import (
+	"fmt"
+
+	sheets "google.golang.org/api/sheets/v4"
+)
+
+// Change of a cell value since the previous run, used to format the cell.
+type CellChange int
+
+const (
+	// Clears the change formatting of the cell.
+	ChangeNone CellChange = iota
+	ChangeUp
+	ChangeDown
+)
+
+// Styles of the change formatting, either the background or the text gets colored.
+const (
+	FormatBackground = "background"
+	FormatText       = "text"
+)
+
+// Colors per change and style as RGB, red for drops and green for rises.
+var changeColors = map[string]map[CellChange][3]float64{
+	FormatBackground: {
+		ChangeUp:   {0.85, 0.92, 0.83},
+		ChangeDown: {0.96, 0.8, 0.8},
+	},
+	FormatText: {
+		ChangeUp:   {0.22, 0.46, 0.11},
+		ChangeDown: {0.8, 0, 0},
+	},
+}
+
+// Helper function which builds the cell data and field mask of a change for a batchUpdate request.
+//
+// ChangeNone sends no color so the field mask resets it to the default.
+func getChangeCellData(change CellChange, style string) (*sheets.CellData, string) {
+	cell := &sheets.CellData{}
+
+	rgb, ok := changeColors[style][change]
+
+	if style == FormatText {
+		if ok {
+			cell.UserEnteredFormat = &sheets.CellFormat{
+				TextFormat: &sheets.TextFormat{ForegroundColor: getColor(rgb)},
+			}
+		}
+
+		return cell, "userEnteredFormat.textFormat.foregroundColor"
+	}
+
+	if ok {
+		cell.UserEnteredFormat = &sheets.CellFormat{BackgroundColor: getColor(rgb)}
+	}
+
+	return cell, "userEnteredFormat.backgroundColor"
+}
+
+// Helper function which converts RGB values to a Sheets color.
+func getColor(rgb [3]float64) *sheets.Color {
+	return &sheets.Color{
+		Red:   rgb[0],
+		Green: rgb[1],
+		Blue:  rgb[2],
+		// Zero values would be omitted.
+		ForceSendFields: []string{"Red", "Green", "Blue"},
+	}
+}
+
+// Helper function which converts RGB values to a hex color like "D9EBD4".
+func getHexColor(rgb [3]float64) string {
+	hex := ""
+
+	for _, value := range rgb {
+		hex += fmt.Sprintf("%02X", int(value*255+0.5))
+	}
+
+	return hex
+}
@@ -0,0 +1,65 @@
+package tables
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// Last column searched for headers (ZZ).
+const maxHeaderColumn = 701
+
+// Resolves header texts in the header row of a tab (empty for the first tab) to their column letters.
+//
+// Headers are compared case insensitive and without surrounding spaces,
+// a missing or duplicated header returns an error.
+func ResolveColumnHeaders(
+	store SheetStore,
+	tab string,
+	headerRow int,
+	headers []string,
+) (map[string]string, error) {
+	values, err := store.GetValuesForCells(
+		sheetref.JoinReference(tab, fmt.Sprintf("A%d", headerRow)),
+		sheetref.JoinReference(tab, fmt.Sprintf("%s%d", sheetref.ColumnLetter(maxHeaderColumn), headerRow)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	columnsMap := make(map[string][]string)
+
+	if len(values) > 0 {
+		for i, value := range values[0] {
+			header := strings.ToLower(strings.TrimSpace(fmt.Sprintf("%v", value)))
+			if header == "" {
+				continue
+			}
+
+			columnsMap[header] = append(columnsMap[header], sheetref.ColumnLetter(i))
+		}
+	}
+
+	resolved := make(map[string]string)
+
+	for _, header := range headers {
+		columns := columnsMap[strings.ToLower(strings.TrimSpace(header))]
+
+		switch len(columns) {
+		case 0:
+			return nil, fmt.Errorf("header %q not found in row %d", header, headerRow)
+		case 1:
+			resolved[header] = columns[0]
+		default:
+			return nil, fmt.Errorf(
+				"header %q found multiple times in row %d (columns %s)",
+				header,
+				headerRow,
+				strings.Join(columns, ", "),
+			)
+		}
+	}
+
+	return resolved, nil
+}
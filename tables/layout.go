@@ -0,0 +1,256 @@
+package tables
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sheets "google.golang.org/api/sheets/v4"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// Locale of created spreadsheets, the query writes prices like "1.234,56€".
+const spreadsheetLocale = "de_DE"
+
+// Cell value written as formula by every store, plain strings starting with "=" stay text on local sheets.
+type Formula string
+
+// Layout of a sheet created by the init sheet command, the defaults match the README example.
+type SheetLayout struct {
+	Title            string
+	ItemColumn       string
+	AmountColumn     string
+	PriceTotalColumn string
+	PriceColumn      string
+	HeaderRow        int
+	StartRow         int
+	EndRow           int
+	LastUpdatedCell  string
+	ErrorCell        string
+	TotalValueCell   string
+	DifferenceCell   string
+	ChangeCell       string
+}
+
+// Returns the default layout with room for the given amount of items.
+func NewSheetLayout(title string, items int) SheetLayout {
+	startRow := 6
+	endRow := startRow + items - 1
+
+	return SheetLayout{
+		Title:            title,
+		ItemColumn:       "B",
+		AmountColumn:     "F",
+		PriceTotalColumn: "H",
+		PriceColumn:      "J",
+		HeaderRow:        4,
+		StartRow:         startRow,
+		EndRow:           endRow,
+		LastUpdatedCell:  "G2",
+		ErrorCell:        "M2",
+		TotalValueCell:   fmt.Sprintf("F%d", endRow+3),
+		DifferenceCell:   fmt.Sprintf("F%d", endRow+4),
+		ChangeCell:       fmt.Sprintf("F%d", endRow+5),
+	}
+}
+
+// Returns the labels, headers and formulas of the layout keyed by cell.
+func (l SheetLayout) getCells() map[string]string {
+	return map[string]string{
+		"B2": l.Title,
+		"F2": "Last Updated",
+		"L2": "Error",
+		fmt.Sprintf("%s%d", l.ItemColumn, l.HeaderRow):       "Name",
+		fmt.Sprintf("%s%d", l.AmountColumn, l.HeaderRow):     "Amount",
+		fmt.Sprintf("%s%d", l.PriceTotalColumn, l.HeaderRow): "Price (Total)",
+		fmt.Sprintf("%s%d", l.PriceColumn, l.HeaderRow):      "Price (Item)",
+		fmt.Sprintf("B%d", l.EndRow+3):                       "Total",
+		fmt.Sprintf("B%d", l.EndRow+4):                       "Difference",
+		fmt.Sprintf("B%d", l.EndRow+5):                       "Change",
+		// Relative change of the last run, empty until the first run.
+		l.ChangeCell: fmt.Sprintf("=IFERROR(%s/(%s-%s))", l.DifferenceCell, l.TotalValueCell, l.DifferenceCell),
+	}
+}
+
+// Writes the labels, headers and formulas of the layout to the first tab.
+//
+// Cells which already hold a different value are reported and nothing gets written,
+// Google sheets also get their labels formatted bold.
+func WriteSheetLayout(store SheetStore, layout SheetLayout) error {
+	cells := layout.getCells()
+
+	lastRow := layout.EndRow + 5
+
+	values, err := store.GetValuesForCells("A1", fmt.Sprintf("%s%d", sheetref.ColumnLetter(maxHeaderColumn), lastRow))
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+
+	for cell, value := range cells {
+		column, row, err := sheetref.ParseCell(cell)
+		if err != nil {
+			return err
+		}
+
+		if row-1 >= len(values) || column >= len(values[row-1]) {
+			continue
+		}
+
+		existing := fmt.Sprintf("%v", values[row-1][column])
+
+		if existing != "" && existing != value {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%q)", cell, existing))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+
+		return fmt.Errorf(
+			"sheet layout cells are not empty, clear them or use a new sheet: %s",
+			strings.Join(conflicts, ", "),
+		)
+	}
+
+	data := make(map[string][][]interface{})
+	for cell, value := range cells {
+		if strings.HasPrefix(value, "=") {
+			data[cell] = [][]interface{}{{Formula(value)}}
+			continue
+		}
+
+		data[cell] = [][]interface{}{{value}}
+	}
+
+	if err := store.BatchWrite(data); err != nil {
+		return err
+	}
+
+	service, ok := store.(*SpreadsheetService)
+	if !ok {
+		return nil
+	}
+
+	var labels []string
+	for cell := range cells {
+		if cell != layout.ChangeCell {
+			labels = append(labels, cell)
+		}
+	}
+
+	return service.formatLayout(labels, layout.ChangeCell)
+}
+
+// Creates a new Google spreadsheet and returns a service using it.
+//
+// The German locale makes Sheets parse the prices the query writes as numbers.
+//
+// Spreadsheets created with a service account are owned by it, share them with your account.
+func CreateSpreadsheet(auth AuthConfig, title string) (*SpreadsheetService, error) {
+	service, err := NewSpreadsheetService(auth, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var spreadsheet *sheets.Spreadsheet
+
+	err = doWithRetry(func() error {
+		spreadsheet, err = service.service.Spreadsheets.Create(&sheets.Spreadsheet{
+			Properties: &sheets.SpreadsheetProperties{Title: title, Locale: spreadsheetLocale},
+		}).Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if spreadsheet.SpreadsheetId == "" {
+		return nil, errors.New("google did not return a spreadsheet id")
+	}
+
+	service.spreadsheetID = spreadsheet.SpreadsheetId
+
+	return service, nil
+}
+
+// Helper function which formats the labels bold and the change cell as percentage.
+func (s *SpreadsheetService) formatLayout(labels []string, changeCell string) error {
+	spreadsheet, err := s.getSheetLayout()
+	if err != nil {
+		return err
+	}
+
+	if len(spreadsheet.Sheets) == 0 {
+		return errors.New("spreadsheet has no tabs")
+	}
+
+	sheetID := spreadsheet.Sheets[0].Properties.SheetId
+
+	sort.Strings(labels)
+
+	var requests []*sheets.Request
+
+	for _, cell := range labels {
+		gridRange, err := getGridRange(sheetID, cell)
+		if err != nil {
+			return err
+		}
+
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Range: gridRange,
+				Cell: &sheets.CellData{
+					UserEnteredFormat: &sheets.CellFormat{
+						TextFormat: &sheets.TextFormat{Bold: true},
+					},
+				},
+				Fields: "userEnteredFormat.textFormat.bold",
+			},
+		})
+	}
+
+	gridRange, err := getGridRange(sheetID, changeCell)
+	if err != nil {
+		return err
+	}
+
+	requests = append(requests, &sheets.Request{
+		RepeatCell: &sheets.RepeatCellRequest{
+			Range: gridRange,
+			Cell: &sheets.CellData{
+				UserEnteredFormat: &sheets.CellFormat{
+					NumberFormat: &sheets.NumberFormat{Type: "PERCENT", Pattern: "0.00%"},
+				},
+			},
+			Fields: "userEnteredFormat.numberFormat",
+		},
+	})
+
+	return doWithRetry(func() error {
+		_, err := s.service.Spreadsheets.BatchUpdate(s.spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
+			Do()
+		return err
+	})
+}
+
+// Helper function which converts a cell without tab to the grid range of a tab.
+func getGridRange(sheetID int64, cell string) (*sheets.GridRange, error) {
+	column, row, err := sheetref.ParseCell(cell)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sheets.GridRange{
+		SheetId:          sheetID,
+		StartRowIndex:    int64(row - 1),
+		EndRowIndex:      int64(row),
+		StartColumnIndex: int64(column),
+		EndColumnIndex:   int64(column + 1),
+		// Zero values would be omitted and turn into unbounded ranges.
+		ForceSendFields: []string{"SheetId", "StartRowIndex", "StartColumnIndex"},
+	}, nil
+}
@@ -0,0 +1,236 @@
+package tables
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// Sheet file storing the sheet as a CSV grid, row 1 is the first line and column A the first field.
+type csvSheetFile struct {
+	path string
+}
+
+// Creates a store backed by a CSV file with the same layout as the Google sheet, created on first write.
+func NewCSVSheetStore(path string) (*MemorySheetStore, error) {
+	store := &MemorySheetStore{file: &csvSheetFile{path: path}, cells: make(map[string]interface{})}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("malformed sheet file %s: %s", path, err.Error())
+	}
+
+	for i, record := range records {
+		for j, value := range record {
+			if value != "" {
+				store.cells[fmt.Sprintf("%s%d", sheetref.ColumnLetter(j), i+1)] = value
+			}
+		}
+	}
+
+	return store, nil
+}
+
+func (c *csvSheetFile) checkWritable() error {
+	return checkFileWritable(c.path)
+}
+
+func (c *csvSheetFile) save(cells map[string]interface{}, _ map[string]interface{}) error {
+	rows := 0
+	columns := 0
+
+	for cell := range cells {
+		column, row, err := sheetref.ParseCell(cell)
+		if err != nil {
+			return err
+		}
+
+		rows = maxInt(rows, row)
+		columns = maxInt(columns, column+1)
+	}
+
+	records := make([][]string, rows)
+
+	for i := range records {
+		records[i] = make([]string, columns)
+	}
+
+	for cell, value := range cells {
+		column, row, _ := sheetref.ParseCell(cell)
+		records[row-1][column] = fmt.Sprintf("%v", value)
+	}
+
+	f, err := os.Create(c.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	writer := csv.NewWriter(f)
+
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Sheet file storing the sheet in a worksheet of an XLSX workbook.
+//
+// Only changed cells are written so formatting and formulas of other cells are kept.
+type xlsxSheetFile struct {
+	path  string
+	sheet string
+	file  *excelize.File
+}
+
+// Creates a store backed by a worksheet of an XLSX workbook, the first worksheet if no name is given.
+//
+// The workbook is created on first write if it does not exist.
+func NewXLSXSheetStore(path, sheetName string) (*MemorySheetStore, error) {
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		file = excelize.NewFile()
+
+		if sheetName != "" {
+			if err := file.SetSheetName(file.GetSheetName(0), sheetName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if sheetName == "" {
+		sheetName = file.GetSheetName(0)
+	}
+
+	index, err := file.GetSheetIndex(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	if index == -1 {
+		return nil, fmt.Errorf("worksheet %s not found in %s", sheetName, path)
+	}
+
+	store := &MemorySheetStore{
+		file:  &xlsxSheetFile{path: path, sheet: sheetName, file: file},
+		tab:   sheetName,
+		cells: make(map[string]interface{}),
+	}
+
+	rows, err := file.GetRows(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		for j, value := range row {
+			if value != "" {
+				store.cells[fmt.Sprintf("%s%d", sheetref.ColumnLetter(j), i+1)] = value
+			}
+		}
+	}
+
+	return store, nil
+}
+
+func (x *xlsxSheetFile) checkWritable() error {
+	return checkFileWritable(x.path)
+}
+
+// Formula values are written as formulas, other values as is.
+func (x *xlsxSheetFile) save(_ map[string]interface{}, changed map[string]interface{}) error {
+	for cell, value := range changed {
+		if formula, ok := value.(Formula); ok {
+			if err := x.file.SetCellFormula(x.sheet, cell, strings.TrimPrefix(string(formula), "=")); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := x.file.SetCellValue(x.sheet, cell, value); err != nil {
+			return err
+		}
+	}
+
+	return x.file.SaveAs(x.path)
+}
+
+// Keeps the rest of the cell style like number formats and borders.
+func (x *xlsxSheetFile) format(changes map[string]CellChange, style string) error {
+	for cell, change := range changes {
+		styleID, err := x.file.GetCellStyle(x.sheet, cell)
+		if err != nil {
+			return err
+		}
+
+		cellStyle, err := x.file.GetStyle(styleID)
+		if err != nil {
+			return err
+		}
+
+		rgb, ok := changeColors[style][change]
+
+		if style == FormatText {
+			if cellStyle.Font == nil {
+				cellStyle.Font = &excelize.Font{}
+			}
+
+			cellStyle.Font.Color = ""
+			if ok {
+				cellStyle.Font.Color = getHexColor(rgb)
+			}
+		} else {
+			cellStyle.Fill = excelize.Fill{}
+			if ok {
+				cellStyle.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{getHexColor(rgb)}}
+			}
+		}
+
+		newStyleID, err := x.file.NewStyle(cellStyle)
+		if err != nil {
+			return err
+		}
+
+		if err := x.file.SetCellStyle(x.sheet, cell, cell, newStyleID); err != nil {
+			return err
+		}
+	}
+
+	return x.file.SaveAs(x.path)
+}
+
+// Removes the temporary files excelize creates for large worksheets.
+func (x *xlsxSheetFile) close() error {
+	return x.file.Close()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
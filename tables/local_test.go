@@ -0,0 +1,213 @@
+package tables
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSheetFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		open func(path string) (*MemorySheetStore, error)
+	}{
+		{
+			name: "json",
+			file: "sheet.json",
+			open: NewFileSheetStore,
+		},
+		{
+			name: "csv",
+			file: "sheet.csv",
+			open: NewCSVSheetStore,
+		},
+		{
+			name: "xlsx",
+			file: "sheet.xlsx",
+			open: func(path string) (*MemorySheetStore, error) {
+				return NewXLSXSheetStore(path, "Items")
+			},
+		},
+	}
+
+	data := map[string][][]interface{}{
+		"A1:C1": {{"item", "amount", "price"}},
+		"A2:C2": {{"Case A", "3", "1,00€"}},
+		"A4:C4": {{"Case B", "1", "0,50€"}},
+	}
+
+	want := map[string]interface{}{
+		"A1": "item",
+		"B1": "amount",
+		"C1": "price",
+		"A2": "Case A",
+		"B2": "3",
+		"C2": "1,00€",
+		"A4": "Case B",
+		"B4": "1",
+		"C4": "0,50€",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+
+			store, err := tt.open(path)
+			if err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+
+			if got := store.Cells(); len(got) != 0 {
+				t.Fatalf("Cells() of missing file = %v, want no cells", got)
+			}
+
+			if err := store.BatchWrite(data); err != nil {
+				t.Fatalf("BatchWrite() error = %v", err)
+			}
+
+			// Cleared cells need to be gone after reopening too.
+			if err := store.WriteSingleEntryToTable("A3", []interface{}{"removed"}); err != nil {
+				t.Fatalf("WriteSingleEntryToTable() error = %v", err)
+			}
+
+			if err := store.WriteSingleEntryToTable("A3", []interface{}{""}); err != nil {
+				t.Fatalf("WriteSingleEntryToTable() error = %v", err)
+			}
+
+			if err := store.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			reopened, err := tt.open(path)
+			if err != nil {
+				t.Fatalf("reopen error = %v", err)
+			}
+			defer reopened.Close()
+
+			if got := reopened.Cells(); !reflect.DeepEqual(got, want) {
+				t.Errorf("Cells() after reopen = %v, want %v", got, want)
+			}
+
+			values, err := reopened.GetValuesForCells("A1", "A4")
+			if err != nil {
+				t.Fatalf("GetValuesForCells() error = %v", err)
+			}
+
+			if len(values) != 4 || values[1][0] != "Case A" || len(values[2]) != 0 || values[3][0] != "Case B" {
+				t.Errorf("GetValuesForCells() = %v, want item column with empty row 3", values)
+			}
+		})
+	}
+}
+
+func TestFileSheetStoreMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		body string
+		open func(path string) (*MemorySheetStore, error)
+	}{
+		{
+			name: "json syntax",
+			file: "sheet.json",
+			body: `{"A1": `,
+			open: NewFileSheetStore,
+		},
+		{
+			name: "json cell",
+			file: "sheet.json",
+			body: `{"1A": "item"}`,
+			open: NewFileSheetStore,
+		},
+		{
+			name: "csv quotes",
+			file: "sheet.csv",
+			body: "item,\"amount\n",
+			open: NewCSVSheetStore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+
+			if err := os.WriteFile(path, []byte(tt.body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := tt.open(path); err == nil {
+				t.Fatal("open() error = nil, want malformed sheet file error")
+			}
+		})
+	}
+}
+
+func TestXLSXSheetStoreFormulas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sheet.xlsx")
+
+	store, err := NewXLSXSheetStore(path, "")
+	if err != nil {
+		t.Fatalf("NewXLSXSheetStore() error = %v", err)
+	}
+
+	if err := store.BatchWrite(map[string][][]interface{}{
+		"A1:B1": {{Formula("=SUM(C1:C2)"), "=not a formula"}},
+	}); err != nil {
+		t.Fatalf("BatchWrite() error = %v", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+
+	formula, err := file.GetCellFormula(sheet, "A1")
+	if err != nil {
+		t.Fatalf("GetCellFormula() error = %v", err)
+	}
+
+	if formula != "SUM(C1:C2)" {
+		t.Errorf("formula of A1 = %q, want SUM(C1:C2)", formula)
+	}
+
+	formula, err = file.GetCellFormula(sheet, "B1")
+	if err != nil {
+		t.Fatalf("GetCellFormula() error = %v", err)
+	}
+
+	value, err := file.GetCellValue(sheet, "B1")
+	if err != nil {
+		t.Fatalf("GetCellValue() error = %v", err)
+	}
+
+	if formula != "" || value != "=not a formula" {
+		t.Errorf("B1 = formula %q, value %q, want plain text", formula, value)
+	}
+}
+
+func TestXLSXSheetStoreTab(t *testing.T) {
+	store, err := NewXLSXSheetStore(filepath.Join(t.TempDir(), "sheet.xlsx"), "Items")
+	if err != nil {
+		t.Fatalf("NewXLSXSheetStore() error = %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CheckReferences([]string{"Items!A1", "A1"}); err != nil {
+		t.Errorf("CheckReferences() error = %v, want nil", err)
+	}
+
+	if err := store.CheckReferences([]string{"Other!A1"}); err == nil {
+		t.Error("CheckReferences() error = nil, want error for unknown tab")
+	}
+}
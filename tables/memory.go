@@ -0,0 +1,384 @@
+package tables
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// In-memory implementation of the SheetStore, optionally backed by a local file.
+//
+// Values are stored per cell in A1 notation and written as is (no formula or number parsing).
+type MemorySheetStore struct {
+	mu   sync.Mutex
+	file sheetFile
+	// Tab name references need to match, empty accepts any tab.
+	tab   string
+	cells map[string]interface{}
+}
+
+// Local file a MemorySheetStore gets persisted to after every write.
+type sheetFile interface {
+	// Changed holds the cells of the last write, cleared cells have an empty value.
+	save(cells map[string]interface{}, changed map[string]interface{}) error
+}
+
+// Local file which can tell whether it is writable without writing to it.
+type sheetWritableChecker interface {
+	checkWritable() error
+}
+
+// Local file holding resources which need to be released on close.
+type sheetCloser interface {
+	close() error
+}
+
+// Local file supporting cell formatting, files without formatting ignore it.
+type sheetFormatter interface {
+	format(changes map[string]CellChange, style string) error
+}
+
+// Sheet file storing a JSON object mapping cells to values.
+type jsonSheetFile struct {
+	path string
+}
+
+func (j *jsonSheetFile) checkWritable() error {
+	return checkFileWritable(j.path)
+}
+
+func (j *jsonSheetFile) save(cells map[string]interface{}, _ map[string]interface{}) error {
+	body, err := json.MarshalIndent(cells, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(j.path, body, 0o644)
+}
+
+// Creates an in-memory store with the given cells, values are not persisted.
+func NewMemorySheetStore(cells map[string]interface{}) *MemorySheetStore {
+	store := &MemorySheetStore{cells: make(map[string]interface{})}
+
+	for cell, value := range cells {
+		store.cells[cell] = value
+	}
+
+	return store
+}
+
+// Creates a store backed by a JSON file mapping cells to values, created on first write.
+func NewFileSheetStore(path string) (*MemorySheetStore, error) {
+	store := &MemorySheetStore{file: &jsonSheetFile{path: path}, cells: make(map[string]interface{})}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return store, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, &store.cells); err != nil {
+		return nil, fmt.Errorf("malformed sheet file %s: %s", path, err.Error())
+	}
+
+	for cell := range store.cells {
+		if _, _, err := sheetref.ParseCell(cell); err != nil {
+			return nil, fmt.Errorf("malformed sheet file %s: %s", path, err.Error())
+		}
+	}
+
+	return store, nil
+}
+
+func (m *MemorySheetStore) TestConnection() error {
+	return nil
+}
+
+func (m *MemorySheetStore) GetValuesForCells(startCell, endCell string) ([][]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cellRange, err := buildRange(startCell, endCell)
+	if err != nil {
+		return nil, err
+	}
+
+	startColumn, startRow, endColumn, endRow, err := m.parseReference(cellRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var values [][]interface{}
+	lastRow := 0
+
+	for row := startRow; row <= endRow; row++ {
+		rowValues := []interface{}{}
+		lastColumn := 0
+
+		for column := startColumn; column <= endColumn; column++ {
+			value, ok := m.cells[fmt.Sprintf("%s%d", sheetref.ColumnLetter(column), row)]
+			if !ok || value == "" {
+				rowValues = append(rowValues, "")
+				continue
+			}
+
+			rowValues = append(rowValues, value)
+			lastColumn = len(rowValues)
+		}
+
+		values = append(values, rowValues[:lastColumn])
+
+		if lastColumn > 0 {
+			lastRow = len(values)
+		}
+	}
+
+	return values[:lastRow], nil
+}
+
+func (m *MemorySheetStore) WriteRange(cellRange string, values [][]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := make(map[string]interface{})
+
+	if err := m.writeRange(cellRange, values, changed); err != nil {
+		return err
+	}
+
+	return m.save(changed)
+}
+
+func (m *MemorySheetStore) WriteSingleEntryToTable(cell string, values []interface{}) error {
+	return m.WriteRange(cell, [][]interface{}{values})
+}
+
+func (m *MemorySheetStore) WriteMultipleEntriesToTable(inputMap map[int]string, column string) error {
+	ranges, err := getColumnRanges(inputMap, column)
+	if err != nil {
+		return err
+	}
+
+	return m.BatchWrite(ranges)
+}
+
+func (m *MemorySheetStore) AppendRows(cell string, values [][]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tab, _ := sheetref.SplitReference(cell)
+
+	startColumn, startRow, _, _, err := m.parseReference(cell)
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, rowValues := range values {
+		width = maxInt(width, len(rowValues))
+	}
+
+	// Appends after the last row with a value in the columns of the new rows.
+	lastRow := startRow - 1
+
+	for existing := range m.cells {
+		column, row, err := sheetref.ParseCell(existing)
+		if err != nil {
+			return err
+		}
+
+		if column >= startColumn && column < startColumn+width {
+			lastRow = maxInt(lastRow, row)
+		}
+	}
+
+	changed := make(map[string]interface{})
+
+	appendCell := sheetref.JoinReference(tab, fmt.Sprintf("%s%d", sheetref.ColumnLetter(startColumn), lastRow+1))
+
+	if err := m.writeRange(appendCell, values, changed); err != nil {
+		return err
+	}
+
+	return m.save(changed)
+}
+
+func (m *MemorySheetStore) BatchWrite(data map[string][][]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := make(map[string]interface{})
+
+	for cellRange, values := range data {
+		if err := m.writeRange(cellRange, values, changed); err != nil {
+			return err
+		}
+	}
+
+	return m.save(changed)
+}
+
+// Returns a copy of all cells, useful to inspect the store after a run.
+func (m *MemorySheetStore) Cells() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cells := make(map[string]interface{})
+	for cell, value := range m.cells {
+		cells[cell] = value
+	}
+
+	return cells
+}
+
+// Writes the values starting at the first cell of the range, like Google Sheets does.
+func (m *MemorySheetStore) writeRange(
+	cellRange string,
+	values [][]interface{},
+	changed map[string]interface{},
+) error {
+	if err := checkRangeSize(cellRange, values); err != nil {
+		return err
+	}
+
+	startColumn, startRow, _, _, err := m.parseReference(cellRange)
+	if err != nil {
+		return err
+	}
+
+	for i, rowValues := range values {
+		for j, value := range rowValues {
+			cell := fmt.Sprintf("%s%d", sheetref.ColumnLetter(startColumn+j), startRow+i)
+
+			if value == nil || value == "" {
+				delete(m.cells, cell)
+				changed[cell] = ""
+				continue
+			}
+
+			m.cells[cell] = value
+			changed[cell] = value
+		}
+	}
+
+	return nil
+}
+
+func (m *MemorySheetStore) CheckReferences(refs []string) error {
+	for _, ref := range refs {
+		if err := sheetref.ValidateReference(ref); err != nil {
+			return err
+		}
+
+		if _, _, _, _, err := m.parseReference(ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MemorySheetStore) FormatChanges(changes map[string]CellChange, style string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cells := make(map[string]CellChange)
+
+	for cell, change := range changes {
+		if _, _, _, _, err := m.parseReference(cell); err != nil {
+			return err
+		}
+
+		_, ref := sheetref.SplitReference(cell)
+		cells[ref] = change
+	}
+
+	formatter, ok := m.file.(sheetFormatter)
+	if !ok {
+		return nil
+	}
+
+	return formatter.format(cells, style)
+}
+
+// Local sheets have no protected cells, all references are read only if the file is.
+func (m *MemorySheetStore) GetReadOnlyCells(refs []string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, ref := range refs {
+		if _, _, _, _, err := m.parseReference(ref); err != nil {
+			return nil, err
+		}
+	}
+
+	checker, ok := m.file.(sheetWritableChecker)
+	if !ok {
+		return nil, nil
+	}
+
+	return nil, checker.checkWritable()
+}
+
+func (m *MemorySheetStore) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	closer, ok := m.file.(sheetCloser)
+	if !ok {
+		return nil
+	}
+
+	return closer.close()
+}
+
+// Helper function which parses a reference, local sheets only have one tab and no named ranges.
+func (m *MemorySheetStore) parseReference(ref string) (int, int, int, int, error) {
+	tab, cellRange := sheetref.SplitReference(ref)
+
+	if tab == "" && sheetref.IsNamedRange(cellRange) {
+		return 0, 0, 0, 0, fmt.Errorf("named range %s is only supported by Google Sheets", ref)
+	}
+
+	if tab != "" && m.tab != "" && tab != m.tab {
+		return 0, 0, 0, 0, fmt.Errorf("tab %q of reference %s not found, local sheet uses %q", tab, ref, m.tab)
+	}
+
+	return sheetref.ParseRange(cellRange)
+}
+
+// Helper function which checks a file can be opened for writing, or created if it does not exist.
+func checkFileWritable(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err == nil {
+		return f.Close()
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("sheet file %s is not writable: %s", path, err.Error())
+	}
+
+	// The file gets created on the first write, so its directory needs to be writable.
+	f, err = os.CreateTemp(filepath.Dir(path), ".steamquery-check-*")
+	if err != nil {
+		return fmt.Errorf("sheet file %s can not be created: %s", path, err.Error())
+	}
+	f.Close()
+
+	return os.Remove(f.Name())
+}
+
+func (m *MemorySheetStore) save(changed map[string]interface{}) error {
+	if m.file == nil {
+		return nil
+	}
+
+	return m.file.save(m.cells, changed)
+}
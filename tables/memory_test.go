@@ -0,0 +1,230 @@
+package tables
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemorySheetStoreWriteMultipleEntriesToTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		cells    map[string]interface{}
+		inputMap map[int]string
+		column   string
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "contiguous rows",
+			inputMap: map[int]string{2: "a", 3: "b", 4: "c"},
+			column:   "B",
+			want:     map[string]interface{}{"B2": "a", "B3": "b", "B4": "c"},
+		},
+		{
+			name:     "rows with gaps",
+			cells:    map[string]interface{}{"B3": "keep"},
+			inputMap: map[int]string{2: "a", 4: "c"},
+			column:   "B",
+			want:     map[string]interface{}{"B2": "a", "B3": "keep", "B4": "c"},
+		},
+		{
+			name:     "column with tab",
+			inputMap: map[int]string{6: "a"},
+			column:   "'My Tab'!C",
+			want:     map[string]interface{}{"C6": "a"},
+		},
+		{
+			name:     "empty values clear cells",
+			cells:    map[string]interface{}{"B2": "old", "B3": "old"},
+			inputMap: map[int]string{2: ""},
+			column:   "B",
+			want:     map[string]interface{}{"B3": "old"},
+		},
+		{
+			name:     "empty map",
+			inputMap: map[int]string{},
+			column:   "B",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid row",
+			inputMap: map[int]string{0: "a"},
+			column:   "B",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemorySheetStore(tt.cells)
+
+			err := store.WriteMultipleEntriesToTable(tt.inputMap, tt.column)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteMultipleEntriesToTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := store.Cells(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cells() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySheetStoreGetValuesForCells(t *testing.T) {
+	store := NewMemorySheetStore(map[string]interface{}{
+		"A1": "item",
+		"B1": "price",
+		"A2": "Case A",
+		"B3": "1,00€",
+		"A5": "Case B",
+	})
+
+	tests := []struct {
+		name      string
+		startCell string
+		endCell   string
+		want      [][]interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "single cell",
+			startCell: "A1",
+			endCell:   "A1",
+			want:      [][]interface{}{{"item"}},
+		},
+		{
+			name:      "column keeps empty rows in between",
+			startCell: "A1",
+			endCell:   "A5",
+			want:      [][]interface{}{{"item"}, {"Case A"}, {}, {}, {"Case B"}},
+		},
+		{
+			name:      "trailing empty rows are omitted",
+			startCell: "A1",
+			endCell:   "A10",
+			want:      [][]interface{}{{"item"}, {"Case A"}, {}, {}, {"Case B"}},
+		},
+		{
+			name:      "trailing empty cells are omitted",
+			startCell: "A1",
+			endCell:   "C3",
+			want:      [][]interface{}{{"item", "price"}, {"Case A"}, {"", "1,00€"}},
+		},
+		{
+			name:      "empty range",
+			startCell: "D1",
+			endCell:   "D5",
+			want:      [][]interface{}{},
+		},
+		{
+			name:      "references with tab",
+			startCell: "'My Tab'!B1",
+			endCell:   "'My Tab'!B3",
+			want:      [][]interface{}{{"price"}, {}, {"1,00€"}},
+		},
+		{
+			name:      "range across tabs",
+			startCell: "Tab1!A1",
+			endCell:   "Tab2!A2",
+			wantErr:   true,
+		},
+		{
+			name:      "named range",
+			startCell: "Items",
+			endCell:   "Items",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.GetValuesForCells(tt.startCell, tt.endCell)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetValuesForCells() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetValuesForCells() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if len(got[i]) != len(tt.want[i]) || (len(got[i]) > 0 && !reflect.DeepEqual(got[i], tt.want[i])) {
+					t.Errorf("GetValuesForCells() row %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemorySheetStoreBatchWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		cells   map[string]interface{}
+		data    map[string][][]interface{}
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "multiple ranges",
+			data: map[string][][]interface{}{
+				"A1:B1": {{"item", "price"}},
+				"A2:A3": {{"Case A"}, {"Case B"}},
+				"D1":    {{"total"}},
+			},
+			want: map[string]interface{}{"A1": "item", "B1": "price", "A2": "Case A", "A3": "Case B", "D1": "total"},
+		},
+		{
+			name:  "empty values clear cells",
+			cells: map[string]interface{}{"A1": "old", "A2": "old"},
+			data:  map[string][][]interface{}{"A1:A2": {{""}, {nil}}},
+			want:  map[string]interface{}{},
+		},
+		{
+			name: "single cell marks the start",
+			data: map[string][][]interface{}{"B2": {{"a", "b"}, {"c"}}},
+			want: map[string]interface{}{"B2": "a", "C2": "b", "B3": "c"},
+		},
+		{
+			name:    "too many rows for range",
+			data:    map[string][][]interface{}{"A1:A2": {{"a"}, {"b"}, {"c"}}},
+			wantErr: true,
+		},
+		{
+			name:    "too many columns for range",
+			data:    map[string][][]interface{}{"A1:A1": {{"a", "b"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid range",
+			data:    map[string][][]interface{}{"1A": {{"a"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemorySheetStore(tt.cells)
+
+			err := store.BatchWrite(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BatchWrite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := store.Cells(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cells() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
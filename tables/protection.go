@@ -0,0 +1,174 @@
+package tables
+
+import (
+	"fmt"
+
+	sheets "google.golang.org/api/sheets/v4"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// Returns the references inside protected ranges the caller may not edit.
+//
+// Ranges protected with a warning only are writable. Nothing gets written, so view only access
+// to the whole spreadsheet is not detected here.
+func (s *SpreadsheetService) GetReadOnlyCells(refs []string) ([]string, error) {
+	var spreadsheet *sheets.Spreadsheet
+
+	err := doWithRetry(func() error {
+		var err error
+
+		spreadsheet, err = s.service.Spreadsheets.Get(s.spreadsheetID).
+			Fields(
+				"sheets(properties(sheetId,title),protectedRanges(range,namedRangeId,requestingUserCanEdit,warningOnly,unprotectedRanges))",
+				"namedRanges(namedRangeId,name,range)",
+			).
+			Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spreadsheet.Sheets) == 0 {
+		return nil, fmt.Errorf("spreadsheet has no tabs")
+	}
+
+	// References without a tab use the first tab.
+	sheetIDs := map[string]int64{"": spreadsheet.Sheets[0].Properties.SheetId}
+	for _, sheet := range spreadsheet.Sheets {
+		sheetIDs[sheet.Properties.Title] = sheet.Properties.SheetId
+	}
+
+	namedRanges := make(map[string]*sheets.NamedRange)
+	for _, namedRange := range spreadsheet.NamedRanges {
+		namedRanges[namedRange.Name] = namedRange
+		namedRanges[namedRange.NamedRangeId] = namedRange
+	}
+
+	var protectedRanges []*sheets.ProtectedRange
+	for _, sheet := range spreadsheet.Sheets {
+		for _, protectedRange := range sheet.ProtectedRanges {
+			if protectedRange.RequestingUserCanEdit || protectedRange.WarningOnly {
+				continue
+			}
+
+			// Protected named ranges only reference the named range.
+			if protectedRange.Range == nil {
+				namedRange, ok := namedRanges[protectedRange.NamedRangeId]
+				if !ok {
+					continue
+				}
+
+				protectedRange.Range = namedRange.Range
+			}
+
+			protectedRanges = append(protectedRanges, protectedRange)
+		}
+	}
+
+	var readOnly []string
+
+	for _, ref := range refs {
+		gridRange, err := getReferenceGridRange(ref, sheetIDs, namedRanges)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, protectedRange := range protectedRanges {
+			if isProtected(protectedRange, gridRange) {
+				readOnly = append(readOnly, ref)
+				break
+			}
+		}
+	}
+
+	return readOnly, nil
+}
+
+// Helper function which converts a reference (cell, range or named range) to its grid range.
+func getReferenceGridRange(
+	ref string,
+	sheetIDs map[string]int64,
+	namedRanges map[string]*sheets.NamedRange,
+) (*sheets.GridRange, error) {
+	tab, cellRange := sheetref.SplitReference(ref)
+
+	if tab == "" && sheetref.IsNamedRange(cellRange) {
+		namedRange, ok := namedRanges[cellRange]
+		if !ok {
+			return nil, fmt.Errorf("named range %s not found in spreadsheet", cellRange)
+		}
+
+		return namedRange.Range, nil
+	}
+
+	sheetID, ok := sheetIDs[tab]
+	if !ok {
+		return nil, fmt.Errorf("tab %q of reference %s not found in spreadsheet", tab, ref)
+	}
+
+	startColumn, startRow, endColumn, endRow, err := sheetref.ParseRange(cellRange)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sheets.GridRange{
+		SheetId:          sheetID,
+		StartRowIndex:    int64(startRow - 1),
+		EndRowIndex:      int64(endRow),
+		StartColumnIndex: int64(startColumn),
+		EndColumnIndex:   int64(endColumn + 1),
+	}, nil
+}
+
+// Helper function which returns true if the protected range covers part of the grid range
+// which is not excluded by one of its unprotected ranges.
+func isProtected(protectedRange *sheets.ProtectedRange, gridRange *sheets.GridRange) bool {
+	if !gridRangesOverlap(protectedRange.Range, gridRange) {
+		return false
+	}
+
+	for _, unprotected := range protectedRange.UnprotectedRanges {
+		if gridRangeContains(unprotected, gridRange) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Unset end indexes of a grid range are unbounded.
+func gridRangesOverlap(a, b *sheets.GridRange) bool {
+	if a.SheetId != b.SheetId {
+		return false
+	}
+
+	return indexesOverlap(a.StartRowIndex, a.EndRowIndex, b.StartRowIndex, b.EndRowIndex) &&
+		indexesOverlap(a.StartColumnIndex, a.EndColumnIndex, b.StartColumnIndex, b.EndColumnIndex)
+}
+
+func gridRangeContains(outer, inner *sheets.GridRange) bool {
+	if outer.SheetId != inner.SheetId {
+		return false
+	}
+
+	return indexesContain(outer.StartRowIndex, outer.EndRowIndex, inner.StartRowIndex, inner.EndRowIndex) &&
+		indexesContain(outer.StartColumnIndex, outer.EndColumnIndex, inner.StartColumnIndex, inner.EndColumnIndex)
+}
+
+func indexesOverlap(startA, endA, startB, endB int64) bool {
+	return (endB == 0 || startA < endB) && (endA == 0 || startB < endA)
+}
+
+func indexesContain(outerStart, outerEnd, innerStart, innerEnd int64) bool {
+	if innerStart < outerStart {
+		return false
+	}
+
+	if outerEnd == 0 {
+		return true
+	}
+
+	return innerEnd != 0 && innerEnd <= outerEnd
+}
@@ -0,0 +1,29 @@
+package tables
+
+import (
+	"fmt"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// Helper function which builds the range between two references of the same tab.
+//
+// Equal references return the reference itself, which also covers named ranges.
+func buildRange(startRef, endRef string) (string, error) {
+	if startRef == endRef {
+		return startRef, nil
+	}
+
+	startTab, startCell := sheetref.SplitReference(startRef)
+	endTab, endCell := sheetref.SplitReference(endRef)
+
+	if sheetref.IsNamedRange(startCell) || sheetref.IsNamedRange(endCell) {
+		return "", fmt.Errorf("named ranges can not be combined: %s, %s", startRef, endRef)
+	}
+
+	if startTab != endTab {
+		return "", fmt.Errorf("range spans multiple tabs: %s, %s", startRef, endRef)
+	}
+
+	return sheetref.JoinReference(startTab, fmt.Sprintf("%s:%s", startCell, endCell)), nil
+}
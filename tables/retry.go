@@ -0,0 +1,238 @@
+package tables
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+
+	"github.com/devusSs/steamquery-v2/logging"
+)
+
+// Defaults used if the corresponding values are not specified in the config.
+const (
+	defaultMaxRetries        = 5
+	defaultRequestsPerMinute = 60
+	initialRetryBackoff      = time.Second
+	maxRetryBackoff          = 64 * time.Second
+)
+
+// MaxRetries value which disables retries.
+const NoRetries = -1
+
+// Policy for retrying failed Google Sheets requests and limiting the requests per minute.
+//
+// MaxRetries of 0 uses the default, NoRetries disables retries.
+type RequestPolicy struct {
+	MaxRetries        int
+	RequestsPerMinute int
+}
+
+// Google Sheets requests since the last reset, retries count as requests.
+type RequestStats struct {
+	Requests      int
+	Retries       int
+	QuotaErrors   int
+	PeakPerMinute int
+	Throttled     time.Duration
+}
+
+// Counts the requests of the last minute and waits once the budget is used up.
+type requestBudget struct {
+	mu    sync.Mutex
+	sent  []time.Time
+	stats RequestStats
+}
+
+var (
+	requestPolicy = RequestPolicy{
+		MaxRetries:        defaultMaxRetries,
+		RequestsPerMinute: defaultRequestsPerMinute,
+	}
+	budget = &requestBudget{}
+)
+
+// Sets the request policy and applies defaults for unset values.
+func SetRequestPolicy(policy RequestPolicy) {
+	switch policy.MaxRetries {
+	case 0:
+		policy.MaxRetries = defaultMaxRetries
+	case NoRetries:
+		policy.MaxRetries = 0
+	}
+
+	if policy.RequestsPerMinute == 0 {
+		policy.RequestsPerMinute = defaultRequestsPerMinute
+	}
+
+	requestPolicy = policy
+}
+
+// Returns the request stats since the last reset.
+func GetRequestStats() RequestStats {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	return budget.stats
+}
+
+// Resets the request stats, requests of the last minute still count against the budget.
+func ResetRequestStats() {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	budget.stats = RequestStats{}
+}
+
+// Returns the per minute request budget.
+func GetRequestBudget() int {
+	return requestPolicy.RequestsPerMinute
+}
+
+// Waits until the request fits into the per minute budget and counts it.
+func (b *requestBudget) take() {
+	for {
+		b.mu.Lock()
+
+		now := time.Now()
+
+		for len(b.sent) > 0 && now.Sub(b.sent[0]) >= time.Minute {
+			b.sent = b.sent[1:]
+		}
+
+		if len(b.sent) < requestPolicy.RequestsPerMinute {
+			b.sent = append(b.sent, now)
+			b.stats.Requests++
+
+			if len(b.sent) > b.stats.PeakPerMinute {
+				b.stats.PeakPerMinute = len(b.sent)
+			}
+
+			b.mu.Unlock()
+			return
+		}
+
+		wait := b.sent[0].Add(time.Minute).Sub(now)
+		b.stats.Throttled += wait
+
+		b.mu.Unlock()
+
+		logging.LogDebug(fmt.Sprintf("Google Sheets request budget used up, waiting %v", wait.Round(time.Second)))
+
+		time.Sleep(wait)
+	}
+}
+
+func (b *requestBudget) countRetry(quotaError bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.Retries++
+
+	if quotaError {
+		b.stats.QuotaErrors++
+	}
+}
+
+// Helper function which runs a Google Sheets request within the budget.
+//
+// Quota and server errors get retried with exponential backoff, other errors are returned as is.
+func doWithRetry(request func() error) error {
+	return doWithRetryOn(request, isRetryableError)
+}
+
+// Helper function which runs a request which must not run twice, like an append.
+//
+// Only quota errors get retried since those requests were rejected before being applied,
+// a server error may come after the request was applied.
+func doWithQuotaRetry(request func() error) error {
+	return doWithRetryOn(request, isQuotaError)
+}
+
+func doWithRetryOn(request func() error, retryable func(err error) bool) error {
+	backoff := initialRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		budget.take()
+
+		err := request()
+		if err == nil || !retryable(err) || attempt >= requestPolicy.MaxRetries {
+			return err
+		}
+
+		budget.countRetry(isQuotaError(err))
+
+		// Jitter keeps parallel requests from retrying at the same time.
+		wait := backoff + time.Duration(rand.Int63n(int64(time.Second)))
+
+		logging.LogWarning(
+			fmt.Sprintf(
+				"Google Sheets request failed (attempt %d of %d), retrying in %v: %s",
+				attempt+1,
+				requestPolicy.MaxRetries+1,
+				wait.Round(time.Millisecond),
+				err.Error(),
+			),
+		)
+
+		time.Sleep(wait)
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// Helper function which returns true for quota and temporary server errors.
+func isRetryableError(err error) bool {
+	if isQuotaError(err) {
+		return true
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.Code {
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// Helper function which returns true if the error is caused by an exceeded Sheets quota.
+//
+// Sheets returns 429, older responses use 403 with a rate limit reason.
+func isQuotaError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+
+	if apiErr.Code != http.StatusForbidden {
+		return false
+	}
+
+	for _, item := range apiErr.Errors {
+		switch item.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded", "quotaExceeded":
+			return true
+		}
+	}
+
+	return false
+}
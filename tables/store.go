@@ -0,0 +1,138 @@
+package tables
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devusSs/steamquery-v2/sheetref"
+)
+
+// Storage for the sheet values the query reads and writes.
+//
+// Implemented by the Google Sheets SpreadsheetService and the MemorySheetStore,
+// cells and ranges use A1 notation. Features only some stores support are separate
+// interfaces callers check for, like NamedRangeResolver.
+type SheetStore interface {
+	TestConnection() error
+	// Reads a range, trailing empty rows and cells are omitted like Google Sheets does.
+	GetValuesForCells(startCell, endCell string) ([][]interface{}, error)
+	WriteRange(cellRange string, values [][]interface{}) error
+	WriteSingleEntryToTable(cell string, values []interface{}) error
+	WriteMultipleEntriesToTable(inputMap map[int]string, column string) error
+	// Writes multiple ranges at once, keyed by range.
+	BatchWrite(data map[string][][]interface{}) error
+	// Checks that the tabs and named ranges of the references exist.
+	CheckReferences(refs []string) error
+	// Releases the resources of the store, call it on shutdown.
+	Close() error
+}
+
+// Store supporting named ranges, only Google Sheets.
+type NamedRangeResolver interface {
+	// Resolves a named range to its A1 range including the tab.
+	ResolveNamedRange(name string) (string, error)
+}
+
+// Store which can append rows after the last row of a table.
+type RowAppender interface {
+	// Appends rows after the last row of the table starting at the cell.
+	AppendRows(cell string, values [][]interface{}) error
+}
+
+// Store supporting cell formatting.
+type ChangeFormatter interface {
+	// Colors cells by their change using the style, FormatBackground or FormatText.
+	FormatChanges(changes map[string]CellChange, style string) error
+}
+
+// Store which can tell which cells are not writable.
+type ReadOnlyChecker interface {
+	// Returns the references the caller can not write to, without writing anything.
+	GetReadOnlyCells(refs []string) ([]string, error)
+}
+
+// Helper function which builds the ranges and values of a column write, keyed by range.
+//
+// Every contiguous run of rows gets its own range so rows missing in the map are left untouched.
+// The column may include a tab like "Tab!B".
+func getColumnRanges(inputMap map[int]string, column string) (map[string][][]interface{}, error) {
+	if len(inputMap) == 0 {
+		return nil, errors.New("no entries to write")
+	}
+
+	if err := sheetref.ValidateColumn(column); err != nil {
+		return nil, err
+	}
+
+	var rows []int
+	for row := range inputMap {
+		if row < 1 {
+			return nil, fmt.Errorf("invalid row %d for column %s", row, column)
+		}
+
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+
+	tab, column := sheetref.SplitReference(column)
+
+	ranges := make(map[string][][]interface{})
+
+	for start := 0; start < len(rows); {
+		end := start
+		for end+1 < len(rows) && rows[end+1] == rows[end]+1 {
+			end++
+		}
+
+		var values [][]interface{}
+		for _, row := range rows[start : end+1] {
+			values = append(values, []interface{}{inputMap[row]})
+		}
+
+		cellRange := sheetref.JoinReference(tab, fmt.Sprintf("%s%d:%s%d", column, rows[start], column, rows[end]))
+		ranges[cellRange] = values
+
+		start = end + 1
+	}
+
+	return ranges, nil
+}
+
+// Helper function which checks that the values fill a range like "B6:B28" exactly.
+//
+// Guards against values shifting into the wrong rows, single cells and named ranges only mark the start.
+func checkRangeSize(cellRange string, values [][]interface{}) error {
+	_, ref := sheetref.SplitReference(cellRange)
+	if !strings.Contains(ref, ":") {
+		return nil
+	}
+
+	startColumn, startRow, endColumn, endRow, err := sheetref.ParseRange(ref)
+	if err != nil {
+		return err
+	}
+
+	if len(values) != endRow-startRow+1 {
+		return fmt.Errorf(
+			"got %d row(s) of values for range %s with %d row(s)",
+			len(values),
+			cellRange,
+			endRow-startRow+1,
+		)
+	}
+
+	for _, rowValues := range values {
+		if len(rowValues) > endColumn-startColumn+1 {
+			return fmt.Errorf(
+				"got %d value(s) in a row for range %s with %d column(s)",
+				len(rowValues),
+				cellRange,
+				endColumn-startColumn+1,
+			)
+		}
+	}
+
+	return nil
+}
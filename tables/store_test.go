@@ -0,0 +1,110 @@
+package tables
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetColumnRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputMap map[int]string
+		column   string
+		want     map[string][][]interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "contiguous rows",
+			inputMap: map[int]string{6: "a", 7: "b", 8: "c"},
+			column:   "B",
+			want:     map[string][][]interface{}{"B6:B8": {{"a"}, {"b"}, {"c"}}},
+		},
+		{
+			name:     "rows with gaps",
+			inputMap: map[int]string{6: "a", 7: "b", 9: "d", 12: "g"},
+			column:   "B",
+			want: map[string][][]interface{}{
+				"B6:B7":   {{"a"}, {"b"}},
+				"B9:B9":   {{"d"}},
+				"B12:B12": {{"g"}},
+			},
+		},
+		{
+			name:     "single row",
+			inputMap: map[int]string{28: "z"},
+			column:   "AB",
+			want:     map[string][][]interface{}{"AB28:AB28": {{"z"}}},
+		},
+		{
+			name:     "column with tab",
+			inputMap: map[int]string{1: "a", 2: "b"},
+			column:   "'My Tab'!C",
+			want:     map[string][][]interface{}{"'My Tab'!C1:C2": {{"a"}, {"b"}}},
+		},
+		{
+			name:     "empty map",
+			inputMap: map[int]string{},
+			column:   "B",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid column",
+			inputMap: map[int]string{1: "a"},
+			column:   "B6",
+			wantErr:  true,
+		},
+		{
+			name:     "missing column",
+			inputMap: map[int]string{1: "a"},
+			column:   "",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid row",
+			inputMap: map[int]string{-1: "a", 2: "b"},
+			column:   "B",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getColumnRanges(tt.inputMap, tt.column)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getColumnRanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getColumnRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteMultipleEntriesToTableKeepsUntouchedRows(t *testing.T) {
+	store := NewMemorySheetStore(map[string]interface{}{
+		"B5": "header",
+		"B6": "old 6",
+		"B7": "old 7",
+		"B8": "old 8",
+		"B9": "old 9",
+		"C7": "other column",
+	})
+
+	if err := store.WriteMultipleEntriesToTable(map[int]string{6: "new 6", 8: "new 8"}, "B"); err != nil {
+		t.Fatalf("WriteMultipleEntriesToTable() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"B5": "header",
+		"B6": "new 6",
+		"B7": "old 7",
+		"B8": "new 8",
+		"B9": "old 9",
+		"C7": "other column",
+	}
+
+	if got := store.Cells(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cells() = %v, want %v", got, want)
+	}
+}
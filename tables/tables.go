@@ -7,20 +7,28 @@ import (
 	"time"
 
 	"github.com/devusSs/steamquery-v2/logging"
+	"github.com/devusSs/steamquery-v2/sheetref"
 	"google.golang.org/api/option"
 	sheets "google.golang.org/api/sheets/v4"
 )
 
+// Google Sheets implementation of the SheetStore.
 type SpreadsheetService struct {
 	spreadsheetID string
 	service       *sheets.Service
 }
 
-func NewSpreadsheetService(gCloudConfPath, spreadsheetID string) (*SpreadsheetService, error) {
+func NewSpreadsheetService(auth AuthConfig, spreadsheetID string) (*SpreadsheetService, error) {
 	ctx := context.Background()
+
+	authOption, err := getAuthOption(ctx, auth)
+	if err != nil {
+		return nil, err
+	}
+
 	srv, err := sheets.NewService(
 		ctx,
-		option.WithCredentialsFile(gCloudConfPath),
+		authOption,
 		option.WithScopes(sheets.SpreadsheetsScope),
 	)
 	if err != nil {
@@ -36,28 +44,67 @@ func NewSpreadsheetService(gCloudConfPath, spreadsheetID string) (*SpreadsheetSe
 	return c, nil
 }
 
+// Returns the id of the spreadsheet, useful after creating one.
+func (s *SpreadsheetService) SpreadsheetID() string {
+	return s.spreadsheetID
+}
+
+// Nothing to release, the Sheets client has no open resources.
+func (s *SpreadsheetService) Close() error {
+	return nil
+}
+
 func (s *SpreadsheetService) TestConnection() error {
-	_, err := s.service.Spreadsheets.Values.Get(s.spreadsheetID, "A1:Z1").Do()
-	return err
+	return doWithRetry(func() error {
+		_, err := s.service.Spreadsheets.Values.Get(s.spreadsheetID, "A1:Z1").Do()
+		return err
+	})
 }
 
 func (s *SpreadsheetService) GetValuesForCells(
 	startCell, endCell string,
-) (*sheets.ValueRange, error) {
-	values, err := s.service.Spreadsheets.Values.Get(s.spreadsheetID, fmt.Sprintf("%s:%s", startCell, endCell)).
-		Do()
-	return values, err
+) ([][]interface{}, error) {
+	cellRange, err := buildRange(startCell, endCell)
+	if err != nil {
+		return nil, err
+	}
+
+	var values *sheets.ValueRange
+
+	err = doWithRetry(func() error {
+		values, err = s.service.Spreadsheets.Values.Get(s.spreadsheetID, cellRange).
+			Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values.Values, nil
+}
+
+func (s *SpreadsheetService) WriteRange(cellRange string, values [][]interface{}) error {
+	if err := checkRangeSize(cellRange, values); err != nil {
+		return err
+	}
+
+	return doWithRetry(func() error {
+		_, err := s.service.Spreadsheets.Values.Update(s.spreadsheetID, cellRange, &sheets.ValueRange{Values: values}).
+			ValueInputOption("USER_ENTERED").
+			Do()
+		return err
+	})
 }
 
 func (s *SpreadsheetService) WriteSingleEntryToTable(cell string, values []interface{}) error {
 	var vr sheets.ValueRange
 	vr.Values = append(vr.Values, values)
 
-	_, err := s.service.Spreadsheets.Values.Update(s.spreadsheetID, cell, &vr).
-		ValueInputOption("USER_ENTERED").
-		Do()
-
-	return err
+	return doWithRetry(func() error {
+		_, err := s.service.Spreadsheets.Values.Update(s.spreadsheetID, cell, &vr).
+			ValueInputOption("USER_ENTERED").
+			Do()
+		return err
+	})
 }
 
 func (s *SpreadsheetService) WriteMultipleEntriesToTable(
@@ -66,33 +113,195 @@ func (s *SpreadsheetService) WriteMultipleEntriesToTable(
 ) error {
 	startTime := time.Now()
 
-	var keys []int
-	for key := range inputMap {
-		keys = append(keys, key)
+	ranges, err := getColumnRanges(inputMap, column)
+	if err != nil {
+		return err
 	}
-	sort.Ints(keys)
 
-	var values [][]interface{}
+	// A single batch request no matter how many gaps the column has.
+	err = s.BatchWrite(ranges)
+
+	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
+
+	return err
+}
+
+func (s *SpreadsheetService) AppendRows(cell string, values [][]interface{}) error {
+	// Appends are not idempotent, a retried server error could add the rows twice.
+	return doWithQuotaRetry(func() error {
+		_, err := s.service.Spreadsheets.Values.Append(s.spreadsheetID, cell, &sheets.ValueRange{Values: values}).
+			ValueInputOption("USER_ENTERED").
+			InsertDataOption("INSERT_ROWS").
+			Do()
+		return err
+	})
+}
 
-	for _, key := range keys {
-		values = append(values, []interface{}{inputMap[key]})
+func (s *SpreadsheetService) BatchWrite(data map[string][][]interface{}) error {
+	if len(data) == 0 {
+		return nil
 	}
 
-	valueRange := &sheets.ValueRange{
-		Values: values,
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: "USER_ENTERED"}
+
+	for cellRange, values := range data {
+		if err := checkRangeSize(cellRange, values); err != nil {
+			return err
+		}
+
+		req.Data = append(req.Data, &sheets.ValueRange{Range: cellRange, Values: values})
 	}
 
-	cellRange := fmt.Sprintf(
-		"%s:%s",
-		fmt.Sprintf("%s%d", column, keys[0]),
-		fmt.Sprintf("%s%d", column, keys[len(keys)-1]),
-	)
+	return doWithRetry(func() error {
+		_, err := s.service.Spreadsheets.Values.BatchUpdate(s.spreadsheetID, req).Do()
+		return err
+	})
+}
 
-	_, err := s.service.Spreadsheets.Values.Update(s.spreadsheetID, cellRange, valueRange).
-		ValueInputOption("USER_ENTERED").
-		Do()
+func (s *SpreadsheetService) CheckReferences(refs []string) error {
+	spreadsheet, err := s.getSheetLayout()
+	if err != nil {
+		return err
+	}
 
-	logging.LogDebug(fmt.Sprintf("took %.2f second(s)", time.Since(startTime).Seconds()))
+	tabs := make(map[string]bool)
+	for _, sheet := range spreadsheet.Sheets {
+		tabs[sheet.Properties.Title] = true
+	}
 
-	return err
+	namedRanges := make(map[string]bool)
+	for _, namedRange := range spreadsheet.NamedRanges {
+		namedRanges[namedRange.Name] = true
+	}
+
+	for _, ref := range refs {
+		if err := sheetref.ValidateReference(ref); err != nil {
+			return err
+		}
+
+		tab, rest := sheetref.SplitReference(ref)
+
+		if tab != "" && !tabs[tab] {
+			return fmt.Errorf("tab %q of reference %s not found in spreadsheet", tab, ref)
+		}
+
+		if tab == "" && sheetref.IsNamedRange(rest) && !namedRanges[rest] {
+			return fmt.Errorf("named range %s not found in spreadsheet", rest)
+		}
+	}
+
+	return nil
+}
+
+func (s *SpreadsheetService) ResolveNamedRange(name string) (string, error) {
+	spreadsheet, err := s.getSheetLayout()
+	if err != nil {
+		return "", err
+	}
+
+	for _, namedRange := range spreadsheet.NamedRanges {
+		if namedRange.Name != name {
+			continue
+		}
+
+		gridRange := namedRange.Range
+
+		// Ranges covering whole columns or rows have no end index.
+		if gridRange.EndRowIndex == 0 || gridRange.EndColumnIndex == 0 {
+			return "", fmt.Errorf("named range %s needs to cover a bounded range", name)
+		}
+
+		for _, sheet := range spreadsheet.Sheets {
+			if sheet.Properties.SheetId != gridRange.SheetId {
+				continue
+			}
+
+			return sheetref.JoinReference(sheet.Properties.Title, fmt.Sprintf(
+				"%s%d:%s%d",
+				sheetref.ColumnLetter(int(gridRange.StartColumnIndex)),
+				gridRange.StartRowIndex+1,
+				sheetref.ColumnLetter(int(gridRange.EndColumnIndex-1)),
+				gridRange.EndRowIndex,
+			)), nil
+		}
+
+		return "", fmt.Errorf("tab of named range %s not found in spreadsheet", name)
+	}
+
+	return "", fmt.Errorf("named range %s not found in spreadsheet", name)
+}
+
+func (s *SpreadsheetService) FormatChanges(changes map[string]CellChange, style string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	spreadsheet, err := s.getSheetLayout()
+	if err != nil {
+		return err
+	}
+
+	// References without a tab use the first tab.
+	sheetIDs := make(map[string]int64)
+	for i, sheet := range spreadsheet.Sheets {
+		sheetIDs[sheet.Properties.Title] = sheet.Properties.SheetId
+
+		if i == 0 {
+			sheetIDs[""] = sheet.Properties.SheetId
+		}
+	}
+
+	var cells []string
+	for cell := range changes {
+		cells = append(cells, cell)
+	}
+	sort.Strings(cells)
+
+	var requests []*sheets.Request
+
+	for _, cell := range cells {
+		tab, ref := sheetref.SplitReference(cell)
+
+		sheetID, ok := sheetIDs[tab]
+		if !ok {
+			return fmt.Errorf("tab %q of reference %s not found in spreadsheet", tab, cell)
+		}
+
+		gridRange, err := getGridRange(sheetID, ref)
+		if err != nil {
+			return err
+		}
+
+		cellData, fields := getChangeCellData(changes[cell], style)
+
+		requests = append(requests, &sheets.Request{
+			RepeatCell: &sheets.RepeatCellRequest{
+				Range:  gridRange,
+				Cell:   cellData,
+				Fields: fields,
+			},
+		})
+	}
+
+	return doWithRetry(func() error {
+		_, err := s.service.Spreadsheets.BatchUpdate(s.spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).
+			Do()
+		return err
+	})
+}
+
+// Helper function which fetches the tabs and named ranges of the spreadsheet.
+func (s *SpreadsheetService) getSheetLayout() (*sheets.Spreadsheet, error) {
+	var spreadsheet *sheets.Spreadsheet
+
+	err := doWithRetry(func() error {
+		var err error
+
+		spreadsheet, err = s.service.Spreadsheets.Get(s.spreadsheetID).
+			Fields("sheets.properties(sheetId,title)", "namedRanges(name,range)").
+			Do()
+		return err
+	})
+
+	return spreadsheet, err
 }
@@ -21,8 +21,9 @@ import (
 	"github.com/devusSs/steamquery-v2/utils"
 )
 
-const (
-	updateURL = "https://api.github.com/repos/devusSs/steamquery-v2/releases/latest"
+var (
+	releaseURL = "https://api.github.com/repos/devusSs/steamquery-v2/releases/latest"
+	httpClient = &http.Client{Timeout: 10 * time.Second}
 )
 
 var (
@@ -35,6 +36,19 @@ var (
 	buildGoVersion = runtime.Version()
 )
 
+// Sets the Github release endpoint and HTTP client used for update checks.
+//
+// An empty URL and a nil client keep the defaults.
+func InitUpdater(url string, client *http.Client) {
+	if url != "" {
+		releaseURL = url
+	}
+
+	if client != nil {
+		httpClient = client
+	}
+}
+
 func PrintBuildInfo() {
 	fmt.Printf("Build date: \t  %s\n", BuildDate)
 	fmt.Printf("Build mode: \t  %s\n", BuildMode)
@@ -136,12 +150,20 @@ func PeriodicUpdateCheck(stopCheck chan bool) {
 
 // Queries the latest release from Github repo.
 func findLatestReleaseURL() (string, string, string, error) {
-	resp, err := http.Get(updateURL)
+	resp, err := httpClient.Get(releaseURL)
 	if err != nil {
 		return "", "", "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", "", "", fmt.Errorf(
+			"unwanted Github response: %s (code: %d)",
+			resp.Status,
+			resp.StatusCode,
+		)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", "", "", err
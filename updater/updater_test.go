@@ -0,0 +1,100 @@
+package updater
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Helper function which points the updater at a test server and restores the defaults after the test.
+func useReleaseServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+
+	oldReleaseURL, oldClient := releaseURL, httpClient
+	oldArch, oldOS := buildArch, buildOS
+
+	server := httptest.NewServer(handler)
+
+	t.Cleanup(func() {
+		server.Close()
+
+		releaseURL, httpClient = oldReleaseURL, oldClient
+		buildArch, buildOS = oldArch, oldOS
+	})
+
+	InitUpdater(server.URL, server.Client())
+
+	buildArch = "x86_64"
+	buildOS = "linux"
+}
+
+func TestFindLatestReleaseURLUnwantedResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "not found", status: http.StatusNotFound},
+		{name: "rate limited", status: http.StatusForbidden},
+		{name: "server error", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, `{"message": "error"}`)
+			})
+
+			_, _, _, err := findLatestReleaseURL()
+			if err == nil {
+				t.Fatal("findLatestReleaseURL() error = nil, want error")
+			}
+
+			if !strings.Contains(err.Error(), fmt.Sprintf("code: %d", tt.status)) {
+				t.Errorf("findLatestReleaseURL() error = %v, want status code %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestFindLatestReleaseURL(t *testing.T) {
+	useReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"tag_name": "v1.2.3",
+			"body": "## Changelog\n* fix something",
+			"assets": [
+				{"name": "steamquery_Windows_x86_64.zip", "browser_download_url": "https://example.com/windows"},
+				{"name": "steamquery_Linux_x86_64.tar.gz", "browser_download_url": "https://example.com/linux"}
+			]
+		}`)
+	})
+
+	downloadURL, version, changelog, err := findLatestReleaseURL()
+	if err != nil {
+		t.Fatalf("findLatestReleaseURL() error = %v", err)
+	}
+
+	if downloadURL != "https://example.com/linux" {
+		t.Errorf("download url = %q, want https://example.com/linux", downloadURL)
+	}
+
+	if version != "v1.2.3" {
+		t.Errorf("version = %q, want v1.2.3", version)
+	}
+
+	if !strings.Contains(changelog, "- fix something") {
+		t.Errorf("changelog = %q, want it to contain - fix something", changelog)
+	}
+}
+
+func TestFindLatestReleaseURLNoMatchingAsset(t *testing.T) {
+	useReleaseServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name": "v1.2.3", "assets": [{"name": "steamquery_Darwin_arm64.tar.gz"}]}`)
+	})
+
+	if _, _, _, err := findLatestReleaseURL(); err == nil {
+		t.Fatal("findLatestReleaseURL() error = nil, want error for missing asset")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/mail"
 	"path/filepath"
+	"sort"
+	"strings"
 	"text/template"
 	"time"
 
@@ -104,3 +106,84 @@ func GenerateFailRunSummary(err error) string {
 		time.Now().Local().String(),
 	)
 }
+
+func GenerateSteamUnavailableSummary(err error) string {
+	return fmt.Sprintf(
+		"Your last steamquery-v2 run gave up waiting for Steam.<br>Error: %s<br>Timestamp: %s",
+		err.Error(),
+		time.Now().Local().String(),
+	)
+}
+
+// Generates the per category totals and differences for the run summary, in the given order.
+func GenerateCategoryBreakdown(names []string, totals, differences map[string]float64) string {
+	var b strings.Builder
+
+	b.WriteString("<br>Categories:")
+
+	for _, name := range names {
+		b.WriteString(
+			fmt.Sprintf("<br>- %s: %.2f€ (%+.2f€)", name, totals[name], differences[name]),
+		)
+	}
+
+	return b.String()
+}
+
+// Generates the liquid and locked (trade or market hold) value for the run summary.
+func GenerateHoldValues(liquid, locked float64) string {
+	return fmt.Sprintf("<br>Liquid value: %.2f€<br>Locked value: %.2f€", liquid, locked)
+}
+
+// Generates the inventory changes between two snapshots for the run summary.
+func GenerateInventoryReport(
+	from, to time.Time,
+	acquired, sold, movedToStorage, movedFromStorage map[string]int,
+) string {
+	var b strings.Builder
+
+	b.WriteString(
+		fmt.Sprintf(
+			"<br>Inventory changes (%s - %s):",
+			from.Local().Format("2006-01-02 15:04"),
+			to.Local().Format("2006-01-02 15:04"),
+		),
+	)
+
+	sections := []struct {
+		title string
+		items map[string]int
+	}{
+		{"Acquired", acquired},
+		{"Sold", sold},
+		{"Moved to storage", movedToStorage},
+		{"Moved from storage", movedFromStorage},
+	}
+
+	changes := 0
+
+	for _, section := range sections {
+		if len(section.items) == 0 {
+			continue
+		}
+
+		var names []string
+		for name := range section.items {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		b.WriteString(fmt.Sprintf("<br>%s:", section.title))
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("<br>- %dx %s", section.items[name], name))
+		}
+
+		changes++
+	}
+
+	if changes == 0 {
+		b.WriteString("<br>No changes")
+	}
+
+	return b.String()
+}